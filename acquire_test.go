@@ -0,0 +1,311 @@
+package locket_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+type failingOnceBackend struct {
+	locket.Backend
+	err error
+
+	lock   sync.Mutex
+	failed bool
+}
+
+func (b *failingOnceBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	failed := b.failed
+	b.failed = true
+	b.lock.Unlock()
+
+	if !failed {
+		return nil, 0, b.err
+	}
+	return b.Backend.AcquireKey(sessionID, key, value, lockType, stopCh)
+}
+
+// stoppingBackend sends the ID of every session it creates, and blocks the
+// first AcquireKey until stopCh is closed, the way Consul does when a session
+// ends under an acquire waiting for a held key.
+type stoppingBackend struct {
+	locket.Backend
+	created chan string
+
+	lock    sync.Mutex
+	stopped bool
+}
+
+func (b *stoppingBackend) CreateSession(name string, ttl time.Duration, noChecks bool) (string, error) {
+	id, err := b.Backend.CreateSession(name, ttl, noChecks)
+	if err == nil {
+		b.created <- id
+	}
+	return id, err
+}
+
+func (b *stoppingBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	stopped := b.stopped
+	b.stopped = true
+	b.lock.Unlock()
+
+	if !stopped {
+		<-stopCh
+		return nil, 0, nil
+	}
+	return b.Backend.AcquireKey(sessionID, key, value, lockType, stopCh)
+}
+
+// listingBackend sends the result of every ListPrefix that succeeds.
+type listingBackend struct {
+	locket.Backend
+	listed chan []locket.KeyValue
+}
+
+func newListingBackend(backend locket.Backend) *listingBackend {
+	return &listingBackend{Backend: backend, listed: make(chan []locket.KeyValue, 100)}
+}
+
+func (b *listingBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]locket.KeyValue, uint64, error) {
+	kvs, index, err := b.Backend.ListPrefix(prefix, waitIndex, waitTime)
+	if err == nil {
+		b.listed <- kvs
+	}
+	return kvs, index, err
+}
+
+var _ = Describe("Context API", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+		lockKey string
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		lockKey = locket.LockSchemaPath("some-key")
+	})
+
+	holder := func(key string) string {
+		kv, err := backend.GetKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		if kv == nil {
+			return ""
+		}
+		return kv.Session
+	}
+
+	Describe("Lock.Acquire", func() {
+		It("holds the lock until released", func() {
+			lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+
+			handle, err := lock.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(handle.FencingToken()).NotTo(BeZero())
+			Expect(holder(lockKey)).NotTo(BeEmpty())
+
+			handle.Release()
+			Expect(holder(lockKey)).To(BeEmpty())
+			Consistently(handle.Lost()).ShouldNot(Receive())
+		})
+
+		It("reports a lost lock", func() {
+			lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+
+			handle, err := lock.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			defer handle.Release()
+
+			Expect(backend.DestroySession(holder(lockKey))).To(Succeed())
+			Eventually(handle.Lost()).Should(Receive(HaveOccurred()))
+		})
+
+		It("takes the key as soon as the holder releases it, without waiting out the retry interval", func() {
+			other := locket.NewLockWithBackend(logger, backend, lockKey, []byte("other"), clock, time.Second, ttl)
+			otherHandle, err := other.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Hour, ttl)
+			handles := make(chan *locket.LockHandle, 1)
+			go func() {
+				defer GinkgoRecover()
+				handle, err := lock.Acquire(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				handles <- handle
+			}()
+
+			Eventually(logger).Should(Say("acquiring-lock"))
+			Consistently(handles).ShouldNot(Receive())
+
+			otherHandle.Release()
+
+			var handle *locket.LockHandle
+			Eventually(handles).Should(Receive(&handle))
+			Expect(logger).NotTo(Say("acquire-lock-failed"))
+			handle.Release()
+		})
+
+		It("retries a failed attempt as soon as the holder releases the key", func() {
+			other := locket.NewLockWithBackend(logger, backend, lockKey, []byte("other"), clock, time.Second, ttl)
+			otherHandle, err := other.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			failing := &failingOnceBackend{Backend: backend, err: errors.New("boom")}
+			lock := locket.NewLockWithBackend(logger, failing, lockKey, []byte("a"), clock, time.Minute, ttl)
+
+			handles := make(chan *locket.LockHandle, 1)
+			go func() {
+				defer GinkgoRecover()
+				handle, err := lock.Acquire(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				handles <- handle
+			}()
+
+			Eventually(logger).Should(Say("acquire-lock-failed"))
+			Consistently(handles).ShouldNot(Receive())
+
+			otherHandle.Release()
+
+			var handle *locket.LockHandle
+			Eventually(handles).Should(Receive(&handle))
+			handle.Release()
+		})
+
+		It("keeps retrying when its session ends while it waits for the key", func() {
+			stopping := &stoppingBackend{Backend: backend, created: make(chan string, 10)}
+			lock := locket.NewLockWithBackend(logger, stopping, lockKey, []byte("a"), clock, time.Second, ttl)
+
+			handles := make(chan *locket.LockHandle, 1)
+			errs := make(chan error, 1)
+			go func() {
+				handle, err := lock.Acquire(context.Background())
+				if err != nil {
+					errs <- err
+					return
+				}
+				handles <- handle
+			}()
+
+			var sessionID string
+			Eventually(stopping.created).Should(Receive(&sessionID))
+			Expect(backend.DestroySession(sessionID)).To(Succeed())
+
+			Eventually(logger).Should(Say("acquire-lock-failed"))
+			Consistently(errs).ShouldNot(Receive())
+
+			var handle *locket.LockHandle
+			Eventually(func() chan *locket.LockHandle {
+				clock.Increment(time.Second)
+				return handles
+			}).Should(Receive(&handle))
+			handle.Release()
+		})
+
+		It("gives up when the context is done", func() {
+			other := locket.NewLockWithBackend(logger, backend, lockKey, []byte("other"), clock, time.Second, ttl)
+			otherHandle, err := other.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			defer otherHandle.Release()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errs := make(chan error, 1)
+			go func() {
+				lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+				_, err := lock.Acquire(ctx)
+				errs <- err
+			}()
+
+			Consistently(errs).ShouldNot(Receive())
+			cancel()
+			Eventually(errs).Should(Receive(Equal(context.Canceled)))
+		})
+	})
+
+	Describe("Presence.Acquire", func() {
+		It("keeps the presence until released", func() {
+			presence := locket.NewPresenceWithBackend(logger, backend, "some-presence", []byte("a"), clock, time.Second, ttl)
+
+			handle, err := presence.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holder("some-presence")).NotTo(BeEmpty())
+
+			handle.Release()
+			Expect(holder("some-presence")).To(BeEmpty())
+		})
+	})
+
+	Describe("WatchLeaderWithBackend", func() {
+		It("streams holder changes until the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			events := locket.WatchLeaderWithBackend(ctx, logger, backend, lockKey, clock)
+			Eventually(events).Should(Receive(Equal(locket.LeaderEvent{Key: lockKey, Vacant: true})))
+
+			lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+			handle, err := lock.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			var event locket.LeaderEvent
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Value).To(Equal([]byte("a")))
+
+			cancel()
+			handle.Release()
+			Eventually(events).Should(BeClosed())
+		})
+	})
+
+	Describe("WatchDisappearancesWithBackend", func() {
+		It("sends keys that disappear", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			listing := newListingBackend(backend)
+			disappearances := locket.WatchDisappearancesWithBackend(ctx, logger, listing, "under", clock)
+
+			presence := locket.NewPresenceWithBackend(logger, backend, "under/here", []byte("a"), clock, time.Second, ttl)
+			handle, err := presence.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(listing.listed).Should(Receive(ContainElement(HaveField("Key", "under/here"))))
+
+			handle.Release()
+			Eventually(disappearances).Should(Receive(Equal([]string{"under/here"})))
+		})
+	})
+
+	Describe("WatchKeysWithBackend", func() {
+		It("sends the held keys, and then the keys that appear and disappear", func() {
+			presence := locket.NewPresenceWithBackend(logger, backend, "under/first", []byte("a"), clock, time.Second, ttl)
+			first, err := presence.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes := locket.WatchKeysWithBackend(ctx, logger, backend, "under", clock)
+			Eventually(changes).Should(Receive(Equal(locket.KeyChange{Appeared: []string{"under/first"}})))
+
+			presence = locket.NewPresenceWithBackend(logger, backend, "under/second", []byte("a"), clock, time.Second, ttl)
+			second, err := presence.Acquire(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			defer second.Release()
+			Eventually(changes).Should(Receive(Equal(locket.KeyChange{Appeared: []string{"under/second"}})))
+
+			first.Release()
+			Eventually(changes).Should(Receive(Equal(locket.KeyChange{Disappeared: []string{"under/first"}})))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package locket
+
+import "time"
+
+// Backend is the coordination store underneath a Session. It owns sessions
+// with a TTL, keys bound to those sessions, and blocking prefix queries.
+type Backend interface {
+	CreateSession(name string, ttl time.Duration, noChecks bool) (string, error)
+
+	// RenewSession keeps the session alive until doneCh is closed, when it
+	// destroys it, or until it can no longer be renewed. It calls renewed,
+	// when not nil, with the backend's time once when it starts and then
+	// after every successful renewal.
+	RenewSession(id string, ttl time.Duration, doneCh chan struct{}, renewed func(time.Time)) error
+
+	DestroySession(id string) error
+
+	// SessionInfo returns ErrInvalidSession when the session does not exist.
+	SessionInfo(id string) (*SessionInfo, error)
+
+	// AcquireKey blocks until the key is held by the session or stopCh is
+	// closed, in which case it returns a nil channel. The returned channel
+	// is closed when the key is no longer held. The returned index increases
+	// every time the key changes hands, so it can be used as a fencing token.
+	AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error)
+
+	// TryAcquireKey is AcquireKey without waiting: it returns a nil channel
+	// when the key is held by another session.
+	TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error)
+
+	// AcquireKeys is TryAcquireKey for several keys at once: either the
+	// session ends up holding all of them, with a channel for each in the
+	// same order, or none of them changes hands and it returns one of the
+	// keys held by another session.
+	AcquireKeys(sessionID string, kvs []KeyValue) ([]<-chan struct{}, string, error)
+
+	// ReleaseKey gives up a key held by the session without invalidating the
+	// session. It closes the channel returned when the key was acquired, and
+	// returns ErrInvalidSession when the session does not hold the key.
+	ReleaseKey(sessionID, key string) error
+
+	// UpdateKey rewrites the value of a key held by the session. It returns
+	// ErrInvalidSession when the session does not hold the key.
+	UpdateKey(sessionID, key string, value []byte) error
+
+	// ListPrefix blocks until the prefix changes past waitIndex or waitTime
+	// elapses. It returns nil when nothing exists under the prefix.
+	ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]KeyValue, uint64, error)
+
+	// PutKey writes a key that is not held by any session.
+	PutKey(key string, value []byte) error
+
+	// DeleteKey removes a key written with PutKey.
+	DeleteKey(key string) error
+
+	// GetKey returns nil when the key does not exist.
+	GetKey(key string) (*KeyValue, error)
+
+	// WatchKey is GetKey blocking until the key changes past waitIndex or
+	// waitTime elapses.
+	WatchKey(key string, waitIndex uint64, waitTime time.Duration) (*KeyValue, uint64, error)
+}
+
+// KeyValue is a key and the session holding it, if any. AcquiredAt is zero
+// when the backend does not record when the key was acquired.
+type KeyValue struct {
+	Key        string
+	Value      []byte
+	Session    string
+	Type       string
+	AcquiredAt time.Time
+}
+
+type SessionInfo struct {
+	ID   string
+	Name string
+	Node string
+}
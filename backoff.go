@@ -0,0 +1,95 @@
+package locket
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff decides how long a runner waits before retrying. attempt counts
+// the retries since the last success, starting at 1, and previous is the
+// wait returned for the attempt before it, or 0 for the first. Backoffs keep
+// no state of their own, so one can be shared between runners.
+type Backoff interface {
+	Next(attempt int, previous time.Duration) time.Duration
+}
+
+// ConstantBackoff always waits the same time. It is what runners use when
+// no Backoff is given, with their retry interval.
+type ConstantBackoff time.Duration
+
+func (b ConstantBackoff) Next(attempt int, previous time.Duration) time.Duration {
+	return time.Duration(b)
+}
+
+type exponentialBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// NewExponentialBackoff doubles the wait from base on every attempt, up to
+// max.
+func NewExponentialBackoff(base, max time.Duration) Backoff {
+	return exponentialBackoff{base: base, max: max}
+}
+
+func (b exponentialBackoff) Next(attempt int, previous time.Duration) time.Duration {
+	wait := b.base
+	for i := 1; i < attempt && wait < b.max; i++ {
+		wait *= 2
+	}
+
+	if wait > b.max {
+		return b.max
+	}
+	return wait
+}
+
+type decorrelatedJitterBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// NewDecorrelatedJitterBackoff waits a random time between base and three
+// times the previous wait, up to max, so that runners which failed together
+// spread out their retries.
+func NewDecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return decorrelatedJitterBackoff{base: base, max: max}
+}
+
+func (b decorrelatedJitterBackoff) Next(attempt int, previous time.Duration) time.Duration {
+	if previous < b.base {
+		previous = b.base
+	}
+
+	upper := previous * 3
+	if upper > b.max {
+		upper = b.max
+	}
+	if upper <= b.base {
+		return upper
+	}
+
+	return b.base + time.Duration(rand.Int63n(int64(upper-b.base)))
+}
+
+// retrier walks a Backoff for one retry loop.
+type retrier struct {
+	backoff Backoff
+	attempt int
+	wait    time.Duration
+}
+
+func newRetrier(backoff Backoff) *retrier {
+	return &retrier{backoff: backoff}
+}
+
+func (r *retrier) next() time.Duration {
+	r.attempt++
+	r.wait = r.backoff.Next(r.attempt, r.wait)
+	return r.wait
+}
+
+func (r *retrier) reset() {
+	r.attempt = 0
+	r.wait = 0
+}
@@ -0,0 +1,84 @@
+package locket_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Backoff", func() {
+	Describe("ExponentialBackoff", func() {
+		It("doubles the wait up to the max", func() {
+			backoff := locket.NewExponentialBackoff(time.Second, 5*time.Second)
+
+			Expect(backoff.Next(1, 0)).To(Equal(time.Second))
+			Expect(backoff.Next(2, time.Second)).To(Equal(2 * time.Second))
+			Expect(backoff.Next(3, 2*time.Second)).To(Equal(4 * time.Second))
+			Expect(backoff.Next(4, 4*time.Second)).To(Equal(5 * time.Second))
+			Expect(backoff.Next(100, 5*time.Second)).To(Equal(5 * time.Second))
+		})
+	})
+
+	Describe("DecorrelatedJitterBackoff", func() {
+		It("waits between base and three times the previous wait, up to the max", func() {
+			backoff := locket.NewDecorrelatedJitterBackoff(time.Second, 10*time.Second)
+
+			var wait time.Duration
+			for attempt := 1; attempt < 100; attempt++ {
+				previous := wait
+				wait = backoff.Next(attempt, previous)
+
+				Expect(wait).To(BeNumerically(">=", time.Second))
+				Expect(wait).To(BeNumerically("<=", 10*time.Second))
+				if previous > 0 {
+					Expect(wait).To(BeNumerically("<=", 3*previous))
+				}
+			}
+		})
+	})
+
+	Describe("WithBackoff", func() {
+		var (
+			clock   *fakeclock.FakeClock
+			logger  *lagertest.TestLogger
+			process ifrit.Process
+		)
+
+		BeforeEach(func() {
+			clock = fakeclock.NewFakeClock(time.Now())
+			logger = lagertest.NewTestLogger("locket")
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		})
+
+		It("spaces out a lock's retries", func() {
+			backend := failingBackend{Backend: locket.NewMemoryBackend(clock), err: errors.New("boom")}
+			lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, 10*time.Second,
+				locket.WithBackoff(locket.NewExponentialBackoff(time.Second, time.Minute)))
+			process = ifrit.Background(lock)
+
+			Eventually(logger).Should(Say("acquire-lock-failed"))
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(logger).Should(Say("retrying-acquiring-lock"))
+			Eventually(logger).Should(Say("acquire-lock-failed"))
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Consistently(logger).ShouldNot(Say("retrying-acquiring-lock"))
+
+			clock.Increment(time.Second)
+			Eventually(logger).Should(Say("retrying-acquiring-lock"))
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket/db"
+	"code.cloudfoundry.org/locket/grpcserver"
+	"code.cloudfoundry.org/locket/handlers"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+	"github.com/tedsuo/ifrit/sigmon"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var databaseDriver = flag.String(
+	"databaseDriver",
+	db.SQLite,
+	"database driver: sqlite3, mysql or postgres",
+)
+
+var databaseConnectionString = flag.String(
+	"databaseConnectionString",
+	"locket.db",
+	"connection string for the database",
+)
+
+var maxDatabaseConnections = flag.Int(
+	"maxDatabaseConnections",
+	200,
+	"maximum number of open connections to the database",
+)
+
+var sweepInterval = flag.Duration(
+	"sweepInterval",
+	db.DefaultSweepInterval,
+	"how often to look for locks whose TTL has lapsed",
+)
+
+var listenAddress = flag.String(
+	"listenAddress",
+	"127.0.0.1:8891",
+	"address to serve the Locket gRPC API on",
+)
+
+var caFile = flag.String(
+	"caFile",
+	"",
+	"CA certificate used to verify client certificates",
+)
+
+var certFile = flag.String(
+	"certFile",
+	"",
+	"server certificate; the API is served in plaintext when empty",
+)
+
+var keyFile = flag.String(
+	"keyFile",
+	"",
+	"server private key",
+)
+
+func main() {
+	flag.Parse()
+
+	logger := lager.NewLogger("locket-server")
+	logger.RegisterSink(lager.NewWriterSink(os.Stdout, lager.INFO))
+
+	sqlConn, err := sql.Open(*databaseDriver, *databaseConnectionString)
+	if err != nil {
+		logger.Fatal("failed-to-open-sql", err)
+	}
+	defer sqlConn.Close()
+
+	maxConnections := *maxDatabaseConnections
+	if *databaseDriver == db.SQLite {
+		// SQLite serializes writers; sharing one connection avoids "database is locked"
+		maxConnections = 1
+	}
+	sqlConn.SetMaxOpenConns(maxConnections)
+	sqlConn.SetMaxIdleConns(maxConnections)
+
+	err = sqlConn.Ping()
+	if err != nil {
+		logger.Fatal("sql-failed-to-connect", err)
+	}
+
+	sqlDB := db.NewSQLDB(sqlConn, *databaseDriver)
+	err = sqlDB.CreateLockTable()
+	if err != nil {
+		logger.Fatal("failed-to-create-lock-table", err)
+	}
+
+	tlsConfig, err := serverTLSConfig()
+	if err != nil {
+		logger.Fatal("invalid-tls-config", err)
+	}
+
+	handler := handlers.NewLocketHandler(logger, sqlDB)
+
+	members := grouper.Members{
+		{Name: "sweeper", Runner: db.NewSweeper(logger, sqlDB, clock.NewClock(), *sweepInterval)},
+		{Name: "grpc-server", Runner: grpcserver.NewGRPCServer(logger, *listenAddress, tlsConfig, handler)},
+	}
+
+	group := grouper.NewOrdered(os.Interrupt, members)
+	monitor := ifrit.Invoke(sigmon.New(group))
+
+	logger.Info("started", lager.Data{"driver": *databaseDriver, "started-at": time.Now()})
+
+	err = <-monitor.Wait()
+	if err != nil {
+		logger.Error("exited-with-failure", err)
+		os.Exit(1)
+	}
+
+	logger.Info("exited")
+}
+
+func serverTLSConfig() (*tls.Config, error) {
+	if *certFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if *caFile != "" {
+		caCert, err := os.ReadFile(*caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket"
+	"github.com/hashicorp/consul/api"
+)
+
+var consulCluster = flag.String(
+	"consulCluster",
+	"http://127.0.0.1:8500",
+	"URL of the Consul agent to query",
+)
+
+const usage = `usage: locket [-consulCluster URL] <command>
+
+commands:
+  locks list [-prefix PREFIX]      list the held locks
+  presence list [-prefix PREFIX]   list the held presences
+  lock show KEY                    show the holder of a lock
+  lock release --force -reason REASON KEY
+                                   release a lock on behalf of its holder; only
+                                   locks kept in Consul can be released, not
+                                   those held through a locket server
+  watch PREFIX                     stream keys appearing and disappearing
+  sessions list                    list the Consul sessions
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	apiClient, err := newConsulClient(*consulCluster)
+	if err != nil {
+		fail(err)
+	}
+	client := consuladapter.NewConsulClient(apiClient)
+
+	command, args := flag.Arg(0)+" "+flag.Arg(1), flag.Args()[2:]
+	if flag.Arg(0) == "watch" {
+		command, args = "watch", flag.Args()[1:]
+	}
+
+	switch command {
+	case "locks list":
+		err = listHolders(client, "locks list", locket.LockSchemaRoot, args)
+	case "presence list":
+		err = listHolders(client, "presence list", locket.PresenceSchemaRoot, args)
+	case "lock show":
+		err = showLock(client, args)
+	case "lock release":
+		err = releaseLock(locket.NewConsulAPIBackend(apiClient), args, os.Stdin, os.Stdout)
+	case "watch":
+		err = watch(client, args)
+	case "sessions list":
+		err = listSessions(client)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "locket:", err)
+	os.Exit(1)
+}
+
+// newConsulClient builds the Consul API client itself, rather than through
+// consuladapter, which lock release needs to clean up its revocation when the
+// release fails.
+func newConsulClient(url string) (*api.Client, error) {
+	scheme, address, err := consuladapter.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+
+	config := api.DefaultConfig()
+	config.Address = address
+	config.Scheme = scheme
+
+	return api.NewClient(config)
+}
+
+func listHolders(client consuladapter.Client, name, defaultPrefix string, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	prefix := flags.String("prefix", defaultPrefix, "key prefix to list")
+	flags.Parse(args)
+
+	holders, err := locket.FetchLocks(client, *prefix)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tHOLDER\tSESSION\tNODE\tAGE")
+	for _, holder := range holders {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", holder.Key, holderName(holder), holder.SessionID, holder.Node, age(holder.AcquiredAt))
+	}
+	return w.Flush()
+}
+
+func showLock(client consuladapter.Client, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: locket lock show KEY")
+	}
+
+	holder, err := locket.FetchLock(client, args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "key:\t%s\n", holder.Key)
+	fmt.Fprintf(w, "value:\t%s\n", holder.Value)
+	fmt.Fprintf(w, "session:\t%s\n", holder.SessionID)
+	fmt.Fprintf(w, "session name:\t%s\n", holder.SessionName)
+	fmt.Fprintf(w, "node:\t%s\n", holder.Node)
+	fmt.Fprintf(w, "age:\t%s\n", age(holder.AcquiredAt))
+	if owner := holder.Owner; owner != nil {
+		fmt.Fprintf(w, "owner:\t%s\n", owner.OwnerID)
+		fmt.Fprintf(w, "hostname:\t%s\n", owner.Hostname)
+		fmt.Fprintf(w, "pid:\t%d\n", owner.PID)
+		fmt.Fprintf(w, "version:\t%s\n", owner.Version)
+		for name, value := range owner.Labels {
+			fmt.Fprintf(w, "label %s:\t%s\n", name, value)
+		}
+	}
+	return w.Flush()
+}
+
+// releaseLock asks on out before releasing, and only goes ahead when the
+// answer read from in is "y".
+func releaseLock(backend locket.Backend, args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("lock release", flag.ContinueOnError)
+	flags.SetOutput(out)
+	force := flags.Bool("force", false, "release the lock even though this process does not hold it")
+	operator := flags.String("operator", os.Getenv("USER"), "who is releasing the lock, for the audit entry")
+	reason := flags.String("reason", "", "why the lock is released, for the audit entry")
+	destroySession := flags.Bool("destroy-session", false, "destroy the holder's session, releasing every key it holds")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: locket lock release --force -reason REASON [-operator NAME] [-destroy-session] KEY")
+	}
+	if !*force {
+		return errors.New("releasing a lock held by another process requires --force")
+	}
+	if *reason == "" || *operator == "" {
+		return errors.New("releasing a lock requires a -reason and an -operator")
+	}
+
+	key := flags.Arg(0)
+	holder, err := locket.FetchLockWithBackend(backend, key)
+	if err != nil {
+		return err
+	}
+
+	action := "Release"
+	if *destroySession {
+		action = "Destroy the session of"
+	}
+	fmt.Fprintf(out, "%s %s held by %s (session %s on %s)? [y/N] ", action, key, holderName(holder), holder.SessionID, holder.Node)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return errors.New("aborted")
+	}
+
+	revocation, err := locket.ForceReleaseWithBackend(backend, clock.NewClock(), locket.ForceReleaseRequest{
+		Key:            key,
+		Operator:       *operator,
+		Reason:         *reason,
+		DestroySession: *destroySession,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "released %s, recorded in %s\n", key, locket.RevocationSchemaPath(key, revocation.SessionID))
+	return nil
+}
+
+func watch(client consuladapter.Client, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: locket watch PREFIX")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	logger := lager.NewLogger("locket")
+	logger.RegisterSink(lager.NewWriterSink(os.Stderr, lager.ERROR))
+
+	for change := range locket.WatchKeys(ctx, logger, client, args[0], clock.NewClock()) {
+		now := time.Now().Format(time.RFC3339)
+		for _, key := range change.Appeared {
+			fmt.Printf("%s\tappeared\t%s\n", now, key)
+		}
+		for _, key := range change.Disappeared {
+			fmt.Printf("%s\tdisappeared\t%s\n", now, key)
+		}
+	}
+
+	return nil
+}
+
+func listSessions(client consuladapter.Client) error {
+	sessions, _, err := client.Session().List(nil)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tNODE\tTTL\tBEHAVIOR")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", session.ID, session.Name, session.Node, session.TTL, session.Behavior)
+	}
+	return w.Flush()
+}
+
+// holderName is who holds a key: the owner's host and pid when the value
+// carries an owner envelope, and the raw value otherwise.
+func holderName(holder *locket.LockHolder) string {
+	if owner := holder.Owner; owner != nil {
+		if owner.Hostname != "" {
+			return fmt.Sprintf("%s (%s:%d)", owner.OwnerID, owner.Hostname, owner.PID)
+		}
+		return owner.OwnerID
+	}
+	return string(holder.Value)
+}
+
+func age(since time.Time) string {
+	if since.IsZero() {
+		return "-"
+	}
+	return time.Since(since).Truncate(time.Second).String()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lock release", func() {
+	var (
+		backend *locket.MemoryBackend
+		lockKey string
+		out     *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		clock := fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		lockKey = locket.LockSchemaPath("some-key")
+		out = new(bytes.Buffer)
+
+		lock := locket.NewLockWithBackend(lagertest.NewTestLogger("locket"), backend, lockKey, []byte("some-holder"), clock, time.Second, time.Minute)
+		_, err := lock.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	held := func() bool {
+		kv, err := backend.GetKey(lockKey)
+		Expect(err).NotTo(HaveOccurred())
+		return kv != nil && kv.Session != ""
+	}
+
+	release := func(answer string, args ...string) error {
+		return releaseLock(backend, args, strings.NewReader(answer), out)
+	}
+
+	It("releases the lock once the operator confirms", func() {
+		err := release("y\n", "--force", "-reason", "wedged", "-operator", "someone", lockKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out.String()).To(ContainSubstring("Release " + lockKey + " held by some-holder"))
+		Expect(out.String()).To(ContainSubstring("released " + lockKey))
+		Expect(held()).To(BeFalse())
+	})
+
+	It("asks about the holder's session when told to destroy it", func() {
+		err := release("Y\n", "--force", "-reason", "wedged", "-operator", "someone", "-destroy-session", lockKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out.String()).To(ContainSubstring("Destroy the session of " + lockKey))
+		Expect(held()).To(BeFalse())
+	})
+
+	It("needs exactly one key", func() {
+		Expect(release("y\n", "--force", "-reason", "wedged", "-operator", "someone")).To(MatchError(HavePrefix("usage:")))
+		Expect(release("y\n", "--force", "-reason", "wedged", "-operator", "someone", lockKey, "other-key")).To(MatchError(HavePrefix("usage:")))
+		Expect(held()).To(BeTrue())
+	})
+
+	It("returns an unknown flag instead of exiting", func() {
+		Expect(release("y\n", "--no-such-flag", lockKey)).To(HaveOccurred())
+		Expect(held()).To(BeTrue())
+	})
+
+	It("refuses without --force", func() {
+		err := release("y\n", "-reason", "wedged", "-operator", "someone", lockKey)
+		Expect(err).To(MatchError(ContainSubstring("requires --force")))
+		Expect(out.String()).NotTo(ContainSubstring("[y/N]"))
+		Expect(held()).To(BeTrue())
+	})
+
+	It("refuses without a reason or an operator", func() {
+		Expect(release("y\n", "--force", "-operator", "someone", lockKey)).To(HaveOccurred())
+		Expect(release("y\n", "--force", "-reason", "wedged", "-operator", "", lockKey)).To(HaveOccurred())
+		Expect(held()).To(BeTrue())
+	})
+
+	It("returns a LockNotFoundError for a key nobody holds", func() {
+		otherKey := locket.LockSchemaPath("other-key")
+		err := release("y\n", "--force", "-reason", "wedged", "-operator", "someone", otherKey)
+		Expect(err).To(Equal(locket.LockNotFoundError(otherKey)))
+	})
+
+	for _, answer := range []string{"n\n", "\n", "yes\n", ""} {
+		answer := answer
+
+		It(fmt.Sprintf("aborts on the answer %q", answer), func() {
+			err := release(answer, "--force", "-reason", "wedged", "-operator", "someone", lockKey)
+			Expect(err).To(MatchError("aborted"))
+			Expect(out.String()).To(ContainSubstring("[y/N]"))
+			Expect(held()).To(BeTrue())
+		})
+	}
+})
@@ -0,0 +1,396 @@
+package locket
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"github.com/hashicorp/consul/api"
+)
+
+type consulBackend struct {
+	client consuladapter.Client
+	clock  clock.Clock
+
+	// api is nil when the backend only has the adapter
+	api *api.Client
+}
+
+// NewConsulBackend is a Backend on the operations consuladapter exposes. It
+// has no KV transactions or deletes, so AcquireKeys and DeleteKey fail on it.
+func NewConsulBackend(client consuladapter.Client) Backend {
+	return &consulBackend{client: client, clock: clock.NewClock()}
+}
+
+// NewConsulAPIBackend is a Backend with all of Consul's KV API, for a
+// MultiLock and for ForceRelease.
+func NewConsulAPIBackend(client *api.Client) Backend {
+	return &consulBackend{client: consuladapter.NewConsulClient(client), clock: clock.NewClock(), api: client}
+}
+
+var errConsulAPIRequired = errors.New("needs a Consul backend built with NewConsulAPIBackend")
+
+func (b *consulBackend) CreateSession(name string, ttl time.Duration, noChecks bool) (string, error) {
+	se := &api.SessionEntry{
+		Name:      name,
+		Behavior:  api.SessionBehaviorDelete,
+		TTL:       ttl.String(),
+		LockDelay: 1 * time.Nanosecond,
+	}
+
+	session := b.client.Session()
+	agent := b.client.Agent()
+
+	nodeName, err := agent.NodeName()
+	if err != nil {
+		return "", convertError(err)
+	}
+
+	nodeSessions, _, err := session.Node(nodeName, nil)
+	if err != nil {
+		return "", convertError(err)
+	}
+
+	sessions := findSessions(se.Name, nodeSessions)
+	if sessions != nil {
+		for _, s := range sessions {
+			_, err = session.Destroy(s.ID, nil)
+			if err != nil {
+				return "", convertError(err)
+			}
+		}
+	}
+
+	var f func(*api.SessionEntry, *api.WriteOptions) (string, *api.WriteMeta, error)
+	if noChecks {
+		f = session.CreateNoChecks
+	} else {
+		f = session.Create
+	}
+
+	id, _, err := f(se, nil)
+	if err != nil {
+		return "", convertError(err)
+	}
+
+	return id, nil
+}
+
+// RenewSession is the Consul API's RenewPeriodic, reporting each renewal.
+func (b *consulBackend) RenewSession(id string, ttl time.Duration, doneCh chan struct{}, renewed func(time.Time)) error {
+	session := b.client.Session()
+	waitDur := ttl / 2
+	lastRenewTime := b.clock.Now()
+	if renewed != nil {
+		renewed(lastRenewTime)
+	}
+	var lastErr error
+
+	for {
+		if b.clock.Since(lastRenewTime) > ttl {
+			return convertError(lastErr)
+		}
+
+		timer := b.clock.NewTimer(waitDur)
+		select {
+		case <-timer.C():
+			entry, _, err := session.Renew(id, nil)
+			if err != nil {
+				waitDur = time.Second
+				lastErr = err
+				continue
+			}
+			if entry == nil {
+				return convertError(api.ErrSessionExpired)
+			}
+
+			// the server may have changed the TTL
+			if serverTTL, err := time.ParseDuration(entry.TTL); err == nil {
+				ttl = serverTTL
+			}
+			waitDur = ttl / 2
+			lastRenewTime = b.clock.Now()
+			if renewed != nil {
+				renewed(lastRenewTime)
+			}
+		case <-doneCh:
+			timer.Stop()
+			session.Destroy(id, nil)
+			return nil
+		}
+	}
+}
+
+func (b *consulBackend) DestroySession(id string) error {
+	_, err := b.client.Session().Destroy(id, nil)
+	return convertError(err)
+}
+
+func (b *consulBackend) SessionInfo(id string) (*SessionInfo, error) {
+	entry, _, err := b.client.Session().Info(id, nil)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	if entry == nil {
+		return nil, ErrInvalidSession
+	}
+
+	return &SessionInfo{ID: entry.ID, Name: entry.Name, Node: entry.Node}, nil
+}
+
+func (b *consulBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	return b.acquireKey(sessionID, key, value, false, stopCh)
+}
+
+func (b *consulBackend) TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	return b.acquireKey(sessionID, key, value, true, nil)
+}
+
+func (b *consulBackend) acquireKey(sessionID, key string, value []byte, tryOnce bool, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	lockOptions := api.LockOptions{
+		Key:              key,
+		Value:            value,
+		Session:          sessionID,
+		MonitorRetries:   monitorRetries,
+		MonitorRetryTime: monitorRetryTime,
+	}
+	if tryOnce {
+		lockOptions.LockTryOnce = true
+		lockOptions.LockWaitTime = tryAcquireWaitTime
+	}
+
+	lock, err := b.client.LockOpts(&lockOptions)
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+
+	lostCh, err := lock.Lock(stopCh)
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+	if lostCh == nil {
+		return nil, 0, nil
+	}
+
+	// The key's ModifyIndex is the raft index of our acquisition, which
+	// only ever grows.
+	pair, _, err := b.client.KV().Get(key, nil)
+	if err != nil {
+		b.abandonKey(sessionID, key, lostCh)
+		return nil, 0, convertError(err)
+	}
+	if pair == nil || pair.Session != sessionID {
+		b.abandonKey(sessionID, key, lostCh)
+		return nil, 0, ErrInvalidSession
+	}
+
+	return lostCh, pair.ModifyIndex, nil
+}
+
+// abandonKey releases a key acquired through api.Lock and waits for the
+// lock's monitor to notice and close lostCh, unless the release fails and the
+// monitor is left to give up on its own.
+func (b *consulBackend) abandonKey(sessionID, key string, lostCh <-chan struct{}) {
+	_, _, err := b.client.KV().Release(&api.KVPair{Key: key, Session: sessionID}, nil)
+	if err == nil {
+		<-lostCh
+	}
+}
+
+// tryAcquireWaitTime bounds how long a try-once acquisition waits on a held key.
+const tryAcquireWaitTime = 10 * time.Millisecond
+
+// AcquireKeys locks the keys in one transaction. Consul applies all of its
+// operations or none of them.
+func (b *consulBackend) AcquireKeys(sessionID string, kvs []KeyValue) ([]<-chan struct{}, string, error) {
+	if b.api == nil {
+		return nil, "", errConsulAPIRequired
+	}
+
+	ops := make(api.TxnOps, 0, len(kvs))
+	for _, kv := range kvs {
+		// the flag marks the key as held through api.Lock, which refuses to
+		// contend for keys without it
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVLock, Key: kv.Key, Value: kv.Value, Flags: api.LockFlagValue, Session: sessionID}})
+	}
+
+	ok, response, _, err := b.api.Txn().Txn(ops, nil)
+	if err != nil {
+		return nil, "", convertError(err)
+	}
+	if !ok {
+		if _, err := b.SessionInfo(sessionID); err != nil {
+			return nil, "", err
+		}
+		contended := kvs[0].Key
+		if len(response.Errors) > 0 && response.Errors[0].OpIndex < len(kvs) {
+			contended = kvs[response.Errors[0].OpIndex].Key
+		}
+		return nil, contended, nil
+	}
+
+	lostChs := make([]<-chan struct{}, 0, len(kvs))
+	for i, kv := range kvs {
+		lostCh := make(chan struct{})
+		go b.monitorKey(sessionID, kv.Key, response.Results[i].KV.ModifyIndex, lostCh)
+		lostChs = append(lostChs, lostCh)
+	}
+
+	return lostChs, "", nil
+}
+
+// monitorKey closes lostCh once the session no longer holds the key, or once
+// the key cannot be read for as long as api.Lock would keep trying.
+func (b *consulBackend) monitorKey(sessionID, key string, waitIndex uint64, lostCh chan struct{}) {
+	defer close(lostCh)
+
+	retries := monitorRetries
+	for {
+		pair, meta, err := b.client.KV().Get(key, &api.QueryOptions{WaitIndex: waitIndex})
+		if err != nil {
+			if retries == 0 {
+				return
+			}
+			retries--
+			time.Sleep(monitorRetryTime)
+			continue
+		}
+		if pair == nil || pair.Session != sessionID {
+			return
+		}
+
+		retries = monitorRetries
+		waitIndex = meta.LastIndex
+	}
+}
+
+const (
+	monitorRetries   = 7
+	monitorRetryTime = 2 * time.Second
+)
+
+func (b *consulBackend) ReleaseKey(sessionID, key string) error {
+	released, _, err := b.client.KV().Release(&api.KVPair{Key: key, Session: sessionID}, nil)
+	if err != nil {
+		return convertError(err)
+	}
+	if !released {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+func (b *consulBackend) UpdateKey(sessionID, key string, value []byte) error {
+	if b.api == nil {
+		return b.updateKeyWithAdapter(sessionID, key, value)
+	}
+
+	// the check keeps the lock verb from taking the key back if it was lost
+	ops := api.TxnOps{
+		{KV: &api.KVTxnOp{Verb: api.KVCheckSession, Key: key, Session: sessionID}},
+		{KV: &api.KVTxnOp{Verb: api.KVLock, Key: key, Value: value, Flags: api.LockFlagValue, Session: sessionID}},
+	}
+	ok, _, _, err := b.api.Txn().Txn(ops, nil)
+	if err != nil {
+		return convertError(err)
+	}
+	if !ok {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+// updateKeyWithAdapter rewrites a held key with a plain write, which Consul
+// applies without touching the key's session. Without a transaction the key
+// could change hands between the check and the write, but only once the
+// session has lost it.
+func (b *consulBackend) updateKeyWithAdapter(sessionID, key string, value []byte) error {
+	pair, _, err := b.client.KV().Get(key, nil)
+	if err != nil {
+		return convertError(err)
+	}
+	if pair == nil || pair.Session != sessionID {
+		return ErrInvalidSession
+	}
+
+	_, err = b.client.KV().Put(&api.KVPair{Key: key, Value: value, Flags: pair.Flags}, nil)
+	return convertError(err)
+}
+
+var emptyBytes = []byte{}
+
+func (b *consulBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]KeyValue, uint64, error) {
+	queryOpts := &api.QueryOptions{
+		WaitIndex: waitIndex,
+		WaitTime:  waitTime,
+	}
+
+	pairs, queryMeta, err := b.client.KV().List(prefix, queryOpts)
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+
+	if pairs == nil {
+		// key not found
+		_, err = b.client.KV().Put(&api.KVPair{Key: prefix, Value: emptyBytes}, nil)
+		if err != nil {
+			return nil, 0, convertError(err)
+		}
+		return nil, queryMeta.LastIndex, nil
+	}
+
+	kvs := make([]KeyValue, 0, len(pairs))
+	for _, pair := range pairs {
+		kvs = append(kvs, KeyValue{Key: pair.Key, Value: pair.Value, Session: pair.Session})
+	}
+
+	return kvs, queryMeta.LastIndex, nil
+}
+
+func (b *consulBackend) PutKey(key string, value []byte) error {
+	_, err := b.client.KV().Put(&api.KVPair{Key: key, Value: value}, nil)
+	return convertError(err)
+}
+
+func (b *consulBackend) DeleteKey(key string) error {
+	if b.api == nil {
+		return errConsulAPIRequired
+	}
+
+	_, err := b.api.KV().Delete(key, nil)
+	return convertError(err)
+}
+
+func (b *consulBackend) GetKey(key string) (*KeyValue, error) {
+	kv, _, err := b.WatchKey(key, 0, 0)
+	return kv, err
+}
+
+func (b *consulBackend) WatchKey(key string, waitIndex uint64, waitTime time.Duration) (*KeyValue, uint64, error) {
+	queryOpts := &api.QueryOptions{
+		WaitIndex: waitIndex,
+		WaitTime:  waitTime,
+	}
+
+	pair, queryMeta, err := b.client.KV().Get(key, queryOpts)
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+	if pair == nil {
+		return nil, queryMeta.LastIndex, nil
+	}
+
+	return &KeyValue{Key: pair.Key, Value: pair.Value, Session: pair.Session}, queryMeta.LastIndex, nil
+}
+
+func findSessions(name string, sessions []*api.SessionEntry) []*api.SessionEntry {
+	var matches []*api.SessionEntry
+	for _, session := range sessions {
+		if session.Name == name {
+			matches = append(matches, session)
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,212 @@
+package locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/locket"
+	"github.com/hashicorp/consul/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsulBackend", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		apiClient *api.Client
+		backend   locket.Backend
+		sessionID string
+		key       string
+	)
+
+	BeforeEach(func() {
+		var err error
+		apiClient, err = api.NewClient(&api.Config{Address: consulRunner.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		backend = locket.NewConsulAPIBackend(apiClient)
+		sessionID, err = backend.CreateSession("some-session", ttl, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		key = locket.LockSchemaPath("some-key")
+	})
+
+	Describe("TryAcquireKey", func() {
+		It("acquires a free key and hands out its ModifyIndex as the fencing token", func() {
+			lostCh, index, err := backend.TryAcquireKey(sessionID, key, []byte("some-value"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostCh).NotTo(BeNil())
+
+			pair, _, err := apiClient.KV().Get(key, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pair.Session).To(Equal(sessionID))
+			Expect(pair.Value).To(Equal([]byte("some-value")))
+			Expect(index).To(Equal(pair.ModifyIndex))
+		})
+
+		It("hands a later holder a larger fencing token", func() {
+			_, first, err := backend.TryAcquireKey(sessionID, key, []byte("a"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend.ReleaseKey(sessionID, key)).To(Succeed())
+
+			otherID, err := backend.CreateSession("other-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			_, second, err := backend.TryAcquireKey(otherID, key, []byte("b"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(BeNumerically(">", first))
+		})
+
+		It("does not acquire a key held by another session", func() {
+			_, _, err := backend.TryAcquireKey(sessionID, key, []byte("a"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+
+			otherID, err := backend.CreateSession("other-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			lostCh, _, err := backend.TryAcquireKey(otherID, key, []byte("b"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostCh).To(BeNil())
+		})
+
+		It("closes the lost channel once the session is destroyed", func() {
+			lostCh, _, err := backend.TryAcquireKey(sessionID, key, []byte("a"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.DestroySession(sessionID)).To(Succeed())
+			Eventually(lostCh).Should(BeClosed())
+		})
+	})
+
+	Describe("UpdateKey", func() {
+		BeforeEach(func() {
+			_, _, err := backend.TryAcquireKey(sessionID, key, []byte("a"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rewrites a held key and keeps it held", func() {
+			Expect(backend.UpdateKey(sessionID, key, []byte("b"))).To(Succeed())
+
+			kv, err := backend.GetKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kv.Value).To(Equal([]byte("b")))
+			Expect(kv.Session).To(Equal(sessionID))
+		})
+
+		It("does the same through the adapter alone", func() {
+			adapterBackend := locket.NewConsulBackend(consulRunner.NewClient())
+			Expect(adapterBackend.UpdateKey(sessionID, key, []byte("b"))).To(Succeed())
+
+			kv, err := backend.GetKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kv.Value).To(Equal([]byte("b")))
+			Expect(kv.Session).To(Equal(sessionID))
+		})
+
+		It("does not rewrite a key held by another session", func() {
+			otherID, err := backend.CreateSession("other-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.UpdateKey(otherID, key, []byte("b"))).To(Equal(locket.ErrInvalidSession))
+
+			kv, err := backend.GetKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kv.Value).To(Equal([]byte("a")))
+		})
+	})
+
+	Describe("AcquireKeys", func() {
+		It("acquires every key on the session", func() {
+			keys := []string{locket.LockSchemaPath("a"), locket.LockSchemaPath("b")}
+			lostChs, contended, err := backend.AcquireKeys(sessionID, []locket.KeyValue{
+				{Key: keys[0], Value: []byte("a"), Type: locket.LockType},
+				{Key: keys[1], Value: []byte("b"), Type: locket.LockType},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contended).To(BeEmpty())
+			Expect(lostChs).To(HaveLen(2))
+
+			for _, key := range keys {
+				kv, err := backend.GetKey(key)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(kv.Session).To(Equal(sessionID))
+			}
+
+			Expect(backend.DestroySession(sessionID)).To(Succeed())
+			for _, lostCh := range lostChs {
+				Eventually(lostCh).Should(BeClosed())
+			}
+		})
+
+		It("acquires none of the keys when one of them is held, and names that key", func() {
+			keys := []string{locket.LockSchemaPath("a"), locket.LockSchemaPath("b")}
+
+			otherID, err := backend.CreateSession("other-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = backend.TryAcquireKey(otherID, keys[1], []byte("other"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+
+			lostChs, contended, err := backend.AcquireKeys(sessionID, []locket.KeyValue{
+				{Key: keys[0], Value: []byte("a"), Type: locket.LockType},
+				{Key: keys[1], Value: []byte("b"), Type: locket.LockType},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostChs).To(BeEmpty())
+			Expect(contended).To(Equal(keys[1]))
+
+			Expect(backend.GetKey(keys[0])).To(BeNil())
+			kv, err := backend.GetKey(keys[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kv.Session).To(Equal(otherID))
+		})
+	})
+
+	Describe("WatchKey", func() {
+		It("returns once the key changes after the given index", func() {
+			_, index, err := backend.WatchKey(key, 0, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			watched := make(chan *locket.KeyValue, 1)
+			go func() {
+				defer GinkgoRecover()
+				kv, _, err := backend.WatchKey(key, index, time.Minute)
+				Expect(err).NotTo(HaveOccurred())
+				watched <- kv
+			}()
+			Consistently(watched).ShouldNot(Receive())
+
+			_, _, err = backend.TryAcquireKey(sessionID, key, []byte("a"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+
+			var kv *locket.KeyValue
+			Eventually(watched).Should(Receive(&kv))
+			Expect(kv.Session).To(Equal(sessionID))
+		})
+	})
+
+	Describe("PutKey and DeleteKey", func() {
+		It("writes and removes a key nobody holds", func() {
+			Expect(backend.PutKey(key, []byte("a"))).To(Succeed())
+
+			kv, err := backend.GetKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kv.Value).To(Equal([]byte("a")))
+			Expect(kv.Session).To(BeEmpty())
+
+			Expect(backend.DeleteKey(key)).To(Succeed())
+			Expect(backend.GetKey(key)).To(BeNil())
+		})
+
+		It("only removes the key, not the keys under it", func() {
+			Expect(backend.PutKey(key, []byte("a"))).To(Succeed())
+			Expect(backend.PutKey(key+"/under", []byte("b"))).To(Succeed())
+
+			Expect(backend.DeleteKey(key)).To(Succeed())
+			Expect(backend.GetKey(key + "/under")).NotTo(BeNil())
+		})
+
+		It("cannot delete through the adapter alone", func() {
+			adapterBackend := locket.NewConsulBackend(consulRunner.NewClient())
+			Expect(adapterBackend.DeleteKey(key)).NotTo(Succeed())
+		})
+	})
+})
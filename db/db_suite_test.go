@@ -0,0 +1,38 @@
+package db_test
+
+import (
+	"database/sql"
+
+	"code.cloudfoundry.org/locket/db"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+var (
+	sqlConn *sql.DB
+	sqlDB   *db.SQLDB
+)
+
+func TestDB(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DB Suite")
+}
+
+var _ = BeforeEach(func() {
+	var err error
+	sqlConn, err = sql.Open(db.SQLite, ":memory:")
+	Expect(err).NotTo(HaveOccurred())
+	sqlConn.SetMaxOpenConns(1)
+
+	sqlDB = db.NewSQLDB(sqlConn, db.SQLite)
+	Expect(sqlDB.CreateLockTable()).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	Expect(sqlConn.Close()).To(Succeed())
+})
@@ -0,0 +1,336 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/locket"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
+// Lock is a row of the locks table. ModifiedIndex only changes hands with
+// the lock; Refreshes counts the times its owner has locked it again since.
+type Lock struct {
+	locket.Resource
+	ModifiedIndex int64
+	Refreshes     int64
+	TTL           time.Duration
+}
+
+// SQLDB is a locket.LockStore that keeps locks and presences in a single
+// locks table.
+type SQLDB struct {
+	db     *sql.DB
+	flavor string
+}
+
+func NewSQLDB(db *sql.DB, flavor string) *SQLDB {
+	return &SQLDB{db: db, flavor: flavor}
+}
+
+func (d *SQLDB) CreateLockTable() error {
+	valueType := "BLOB"
+	if d.flavor == Postgres {
+		valueType = "BYTEA"
+	}
+
+	_, err := d.db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS locks (
+			path VARCHAR(255) PRIMARY KEY,
+			owner VARCHAR(255) NOT NULL,
+			value %s,
+			type VARCHAR(255) NOT NULL DEFAULT '',
+			modified_index BIGINT NOT NULL DEFAULT 0,
+			refreshes BIGINT NOT NULL DEFAULT 0,
+			ttl BIGINT NOT NULL DEFAULT 0
+		)`, valueType))
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(d.createTokens())
+	return err
+}
+
+// createTokens creates the sequence fencing tokens are drawn from: a
+// sequence on Postgres, and an auto-increment table elsewhere.
+func (d *SQLDB) createTokens() string {
+	switch d.flavor {
+	case Postgres:
+		return "CREATE SEQUENCE IF NOT EXISTS lock_tokens"
+	case MySQL:
+		return "CREATE TABLE IF NOT EXISTS lock_tokens (id BIGINT AUTO_INCREMENT PRIMARY KEY)"
+	default:
+		return "CREATE TABLE IF NOT EXISTS lock_tokens (id INTEGER PRIMARY KEY AUTOINCREMENT)"
+	}
+}
+
+// Lock writes the resource with a modified index drawn from a sequence shared
+// by all locks when it changes owner, so that the index keeps increasing
+// across owners and can be handed out as a fencing token. Refreshing a lock
+// its owner already holds keeps the index.
+func (d *SQLDB) Lock(resource locket.Resource, ttl time.Duration) (int64, error) {
+	indexes, err := d.LockAll([]locket.Resource{resource}, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	return indexes[0], nil
+}
+
+// LockAll locks the resources in one transaction, so a collision on any of
+// them leaves all of them as they were.
+func (d *SQLDB) LockAll(resources []locket.Resource, ttl time.Duration) ([]int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	indexes := make([]int64, 0, len(resources))
+	for _, resource := range resources {
+		index, err := d.lock(tx, resource, ttl)
+		if errors.Is(err, errInsertFailed) {
+			tx.Rollback()
+			if existing, fetchErr := d.Fetch(resource.Key); fetchErr == nil && existing.Owner != resource.Owner {
+				return nil, locket.ErrLockCollision
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// errInsertFailed wraps the error of an insert by lock, since another owner
+// may have inserted the resource first, which can only be checked outside
+// the transaction.
+var errInsertFailed = errors.New("failed inserting lock")
+
+func (d *SQLDB) lock(tx *sql.Tx, resource locket.Resource, ttl time.Duration) (int64, error) {
+	var owner string
+	var index int64
+	row := tx.QueryRow(d.rebind("SELECT owner, modified_index FROM locks WHERE path = ?"+d.forUpdate()), resource.Key)
+	err := row.Scan(&owner, &index)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil && owner != resource.Owner {
+		return 0, locket.ErrLockCollision
+	}
+
+	if err == nil {
+		_, err = tx.Exec(
+			d.rebind("UPDATE locks SET value = ?, type = ?, refreshes = refreshes + 1, ttl = ? WHERE path = ?"),
+			resource.Value, resource.Type, ttlSeconds(ttl), resource.Key,
+		)
+		return index, err
+	}
+
+	index, err = d.nextIndex(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		d.rebind("INSERT INTO locks (path, owner, value, type, modified_index, ttl) VALUES (?, ?, ?, ?, ?, ?)"),
+		resource.Key, resource.Owner, resource.Value, resource.Type, index, ttlSeconds(ttl),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errInsertFailed, err)
+	}
+
+	return index, nil
+}
+
+func (d *SQLDB) Release(resource locket.Resource) error {
+	result, err := d.db.Exec(d.rebind("DELETE FROM locks WHERE path = ? AND owner = ?"), resource.Key, resource.Owner)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// ReleaseIfUnmodified deletes a lock only if it has not been refreshed since
+// it was last read.
+func (d *SQLDB) ReleaseIfUnmodified(lock Lock) error {
+	result, err := d.db.Exec(
+		d.rebind("DELETE FROM locks WHERE path = ? AND owner = ? AND modified_index = ? AND refreshes = ?"),
+		lock.Key, lock.Owner, lock.ModifiedIndex, lock.Refreshes,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+func (d *SQLDB) Fetch(key string) (*locket.Resource, error) {
+	row := d.db.QueryRow(d.rebind("SELECT path, owner, value, type, modified_index, refreshes, ttl FROM locks WHERE path = ?"), key)
+
+	lock, err := scanLock(row)
+	if err == sql.ErrNoRows {
+		return nil, locket.ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &lock.Resource, nil
+}
+
+func (d *SQLDB) FetchAll(lockType string) ([]locket.Resource, error) {
+	locks, err := d.FetchAllLocks(lockType)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]locket.Resource, 0, len(locks))
+	for _, lock := range locks {
+		resources = append(resources, lock.Resource)
+	}
+
+	return resources, nil
+}
+
+func (d *SQLDB) FetchAllLocks(lockType string) ([]Lock, error) {
+	query := "SELECT path, owner, value, type, modified_index, refreshes, ttl FROM locks"
+	var args []interface{}
+	if lockType != "" {
+		query += " WHERE type = ?"
+		args = append(args, lockType)
+	}
+
+	rows, err := d.db.Query(d.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locks []Lock
+	for rows.Next() {
+		lock, err := scanLock(rows)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+
+	return locks, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLock(row scanner) (Lock, error) {
+	var lock Lock
+	var ttl int64
+
+	err := row.Scan(&lock.Key, &lock.Owner, &lock.Value, &lock.Type, &lock.ModifiedIndex, &lock.Refreshes, &ttl)
+	if err != nil {
+		return Lock{}, err
+	}
+
+	lock.TTL = time.Duration(ttl) * time.Second
+	return lock, nil
+}
+
+func (d *SQLDB) nextIndex(tx *sql.Tx) (int64, error) {
+	if d.flavor == Postgres {
+		var index int64
+		err := tx.QueryRow("SELECT nextval('lock_tokens')").Scan(&index)
+		return index, err
+	}
+
+	insert := "INSERT INTO lock_tokens DEFAULT VALUES"
+	if d.flavor == MySQL {
+		insert = "INSERT INTO lock_tokens () VALUES ()"
+	}
+
+	result, err := tx.Exec(insert)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// PruneTokens deletes all but the latest of the rows the auto-increment
+// token table gains on every change of owner. The latest is kept so that a
+// restarted MySQL does not start the table over.
+func (d *SQLDB) PruneTokens() error {
+	if d.flavor == Postgres {
+		return nil
+	}
+
+	var latest sql.NullInt64
+	err := d.db.QueryRow("SELECT MAX(id) FROM lock_tokens").Scan(&latest)
+	if err != nil || !latest.Valid {
+		return err
+	}
+
+	_, err = d.db.Exec(d.rebind("DELETE FROM lock_tokens WHERE id < ?"), latest.Int64)
+	return err
+}
+
+func ttlSeconds(ttl time.Duration) int64 {
+	return int64((ttl + time.Second - 1) / time.Second)
+}
+
+func requireRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return locket.ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func (d *SQLDB) forUpdate() string {
+	if d.flavor == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// rebind replaces ? placeholders with the $n placeholders Postgres expects.
+func (d *SQLDB) rebind(query string) string {
+	if d.flavor != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,190 @@
+package db_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/locket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLDB", func() {
+	var resource locket.Resource
+
+	BeforeEach(func() {
+		resource = locket.Resource{
+			Key:   "v1/locks/some-key",
+			Owner: "some-owner",
+			Value: []byte("some-value"),
+			Type:  locket.LockType,
+		}
+	})
+
+	Describe("Lock", func() {
+		It("inserts the lock", func() {
+			index, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(index).To(BeEquivalentTo(1))
+
+			locks, err := sqlDB.FetchAllLocks("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locks).To(HaveLen(1))
+			Expect(locks[0].Resource).To(Equal(resource))
+			Expect(locks[0].TTL).To(Equal(10 * time.Second))
+			Expect(locks[0].ModifiedIndex).To(BeEquivalentTo(1))
+		})
+
+		It("keeps increasing the index after a lock is released", func() {
+			first, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sqlDB.Release(resource)).To(Succeed())
+
+			resource.Owner = "other-owner"
+			second, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(BeNumerically(">", first))
+		})
+
+		It("keeps increasing the index after the tokens are pruned", func() {
+			first, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sqlDB.Release(resource)).To(Succeed())
+			Expect(sqlDB.PruneTokens()).To(Succeed())
+
+			second, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(BeNumerically(">", first))
+		})
+
+		It("can create its tables more than once", func() {
+			Expect(sqlDB.CreateLockTable()).To(Succeed())
+		})
+
+		Context("when the owner already holds the lock", func() {
+			BeforeEach(func() {
+				_, err := sqlDB.Lock(resource, 10*time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("refreshes it without drawing a new index", func() {
+				resource.Value = []byte("new-value")
+				index, err := sqlDB.Lock(resource, 10*time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(index).To(BeEquivalentTo(1))
+
+				locks, err := sqlDB.FetchAllLocks("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(locks[0].Value).To(Equal([]byte("new-value")))
+				Expect(locks[0].ModifiedIndex).To(BeEquivalentTo(1))
+				Expect(locks[0].Refreshes).To(BeEquivalentTo(1))
+			})
+		})
+
+		Context("when someone else holds the lock", func() {
+			BeforeEach(func() {
+				other := resource
+				other.Owner = "other-owner"
+				_, err := sqlDB.Lock(other, 10*time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns a collision", func() {
+				_, err := sqlDB.Lock(resource, 10*time.Second)
+				Expect(err).To(Equal(locket.ErrLockCollision))
+			})
+		})
+	})
+
+	Describe("LockAll", func() {
+		var other locket.Resource
+
+		BeforeEach(func() {
+			other = locket.Resource{Key: "v1/locks/other-key", Owner: "some-owner", Type: locket.LockType}
+		})
+
+		It("locks every resource", func() {
+			indexes, err := sqlDB.LockAll([]locket.Resource{resource, other}, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(indexes).To(Equal([]int64{1, 2}))
+
+			locks, err := sqlDB.FetchAllLocks("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locks).To(HaveLen(2))
+		})
+
+		Context("when someone else holds one of the locks", func() {
+			BeforeEach(func() {
+				other.Owner = "other-owner"
+				_, err := sqlDB.Lock(other, 10*time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				other.Owner = "some-owner"
+			})
+
+			It("locks none of them", func() {
+				_, err := sqlDB.LockAll([]locket.Resource{resource, other}, 10*time.Second)
+				Expect(err).To(Equal(locket.ErrLockCollision))
+
+				_, err = sqlDB.Fetch(resource.Key)
+				Expect(err).To(Equal(locket.ErrResourceNotFound))
+			})
+		})
+
+		Context("when the insert fails for another reason", func() {
+			BeforeEach(func() {
+				_, err := sqlConn.Exec(`CREATE TRIGGER reject_locks BEFORE INSERT ON locks BEGIN SELECT RAISE(ABORT, 'locks are read-only'); END`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns the database's error", func() {
+				_, err := sqlDB.LockAll([]locket.Resource{resource, other}, 10*time.Second)
+				Expect(err).To(MatchError(ContainSubstring("locks are read-only")))
+				Expect(err).NotTo(Equal(locket.ErrLockCollision))
+			})
+		})
+	})
+
+	Describe("Release", func() {
+		BeforeEach(func() {
+			_, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("deletes the lock", func() {
+			Expect(sqlDB.Release(resource)).To(Succeed())
+
+			_, err := sqlDB.Fetch(resource.Key)
+			Expect(err).To(Equal(locket.ErrResourceNotFound))
+		})
+
+		It("does not release a lock held by someone else", func() {
+			resource.Owner = "other-owner"
+			Expect(sqlDB.Release(resource)).To(Equal(locket.ErrResourceNotFound))
+
+			_, err := sqlDB.Fetch(resource.Key)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("FetchAll", func() {
+		BeforeEach(func() {
+			_, err := sqlDB.Lock(resource, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			presence := locket.Resource{Key: "v1/locks/cell/some-cell", Owner: "cell", Type: locket.PresenceType}
+			_, err = sqlDB.Lock(presence, 10*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("filters by type", func() {
+			resources, err := sqlDB.FetchAll(locket.PresenceType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(HaveLen(1))
+			Expect(resources[0].Key).To(Equal("v1/locks/cell/some-cell"))
+
+			resources, err = sqlDB.FetchAll("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket"
+)
+
+const DefaultSweepInterval = 1 * time.Second
+
+type observedLock struct {
+	owner         string
+	modifiedIndex int64
+	refreshes     int64
+	observedAt    time.Time
+}
+
+// Sweeper expires locks whose owner has not refreshed them within their TTL,
+// the way Consul deletes the keys of an invalidated session. Expiry is
+// measured on this process's clock from when a lock was last seen to change,
+// so it does not depend on the clocks of the database or the owners.
+type Sweeper struct {
+	logger   lager.Logger
+	db       *SQLDB
+	clock    clock.Clock
+	interval time.Duration
+
+	observed map[string]observedLock
+}
+
+func NewSweeper(logger lager.Logger, db *SQLDB, clock clock.Clock, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		logger:   logger,
+		db:       db,
+		clock:    clock,
+		interval: interval,
+		observed: map[string]observedLock{},
+	}
+}
+
+func (s *Sweeper) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := s.logger.Session("sweeper", lager.Data{"interval": s.interval.String()})
+	logger.Info("starting")
+	defer logger.Info("done")
+
+	ticker := s.clock.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("signalled", lager.Data{"received-signal": sig})
+			return nil
+		case <-ticker.C():
+			s.sweep(logger)
+		}
+	}
+}
+
+func (s *Sweeper) sweep(logger lager.Logger) {
+	locks, err := s.db.FetchAllLocks("")
+	if err != nil {
+		logger.Error("failed-fetching-locks", err)
+		return
+	}
+
+	now := s.clock.Now()
+	seen := map[string]struct{}{}
+
+	for _, lock := range locks {
+		seen[lock.Key] = struct{}{}
+
+		observed, ok := s.observed[lock.Key]
+		if !ok || observed.owner != lock.Owner || observed.modifiedIndex != lock.ModifiedIndex || observed.refreshes != lock.Refreshes {
+			s.observed[lock.Key] = observedLock{
+				owner:         lock.Owner,
+				modifiedIndex: lock.ModifiedIndex,
+				refreshes:     lock.Refreshes,
+				observedAt:    now,
+			}
+			continue
+		}
+
+		if now.Sub(observed.observedAt) < lock.TTL {
+			continue
+		}
+
+		err := s.db.ReleaseIfUnmodified(lock)
+		if err == locket.ErrResourceNotFound {
+			// refreshed or released since it was fetched
+			continue
+		}
+		if err != nil {
+			logger.Error("failed-expiring-lock", err, lager.Data{"key": lock.Key, "owner": lock.Owner})
+			continue
+		}
+
+		logger.Info("expired-lock", lager.Data{"key": lock.Key, "owner": lock.Owner, "type": lock.Type})
+		delete(s.observed, lock.Key)
+	}
+
+	for key := range s.observed {
+		if _, ok := seen[key]; !ok {
+			delete(s.observed, key)
+		}
+	}
+
+	err = s.db.PruneTokens()
+	if err != nil {
+		logger.Error("failed-pruning-tokens", err)
+	}
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/db"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sweeper", func() {
+	const interval = time.Second
+
+	var (
+		clock    *fakeclock.FakeClock
+		process  ifrit.Process
+		resource locket.Resource
+	)
+
+	fetch := func() error {
+		_, err := sqlDB.Fetch(resource.Key)
+		return err
+	}
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		resource = locket.Resource{Key: "some-key", Owner: "some-owner", Type: locket.LockType}
+		_, err := sqlDB.Lock(resource, 3*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+
+		logger := lagertest.NewTestLogger("sweeper")
+		process = ginkgomon.Invoke(db.NewSweeper(logger, sqlDB, clock, interval))
+	})
+
+	AfterEach(func() {
+		ginkgomon.Interrupt(process)
+	})
+
+	It("expires a lock that is not refreshed within its TTL", func() {
+		Eventually(func() error {
+			clock.Increment(interval)
+			return fetch()
+		}).Should(Equal(locket.ErrResourceNotFound))
+	})
+
+	It("keeps a lock that is refreshed", func() {
+		for i := 0; i < 8; i++ {
+			clock.WaitForWatcherAndIncrement(interval)
+			_, err := sqlDB.Lock(resource, 3*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Consistently(fetch).Should(Succeed())
+	})
+})
@@ -0,0 +1,165 @@
+package locket
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugSession is a session created by this process.
+type DebugSession struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	TTL         string    `json:"ttl"`
+	Keys        []string  `json:"keys"`
+	LastRenewal time.Time `json:"last_renewal"`
+}
+
+// DebugRunner is the status of a runner started in this process.
+type DebugRunner struct {
+	Kind                string      `json:"kind"`
+	Key                 string      `json:"key"`
+	State               RunnerState `json:"state"`
+	SessionID           string      `json:"session_id,omitempty"`
+	Since               time.Time   `json:"since"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	LastError           string      `json:"last_error,omitempty"`
+}
+
+// DebugInfo is everything locket is doing in this process.
+type DebugInfo struct {
+	Sessions []DebugSession `json:"sessions"`
+	Runners  []DebugRunner  `json:"runners"`
+}
+
+type runnerEntry struct {
+	kind   string
+	key    string
+	status *statusTracker
+}
+
+// activityRegistry tracks the live sessions and running runners of the
+// process. Sessions add themselves with their own lock held, so the registry
+// never takes a session lock with its own lock held.
+type activityRegistry struct {
+	lock     sync.Mutex
+	sessions map[*Session]struct{}
+	runners  map[*runnerEntry]struct{}
+}
+
+var activity = &activityRegistry{
+	sessions: map[*Session]struct{}{},
+	runners:  map[*runnerEntry]struct{}{},
+}
+
+func (r *activityRegistry) addSession(s *Session) {
+	r.lock.Lock()
+	r.sessions[s] = struct{}{}
+	r.lock.Unlock()
+}
+
+func (r *activityRegistry) removeSession(s *Session) {
+	r.lock.Lock()
+	delete(r.sessions, s)
+	r.lock.Unlock()
+}
+
+// addRunner tracks a runner until the returned function is called.
+func (r *activityRegistry) addRunner(kind, key string, status *statusTracker) func() {
+	entry := &runnerEntry{kind: kind, key: key, status: status}
+
+	r.lock.Lock()
+	r.runners[entry] = struct{}{}
+	r.lock.Unlock()
+
+	return func() {
+		r.lock.Lock()
+		delete(r.runners, entry)
+		r.lock.Unlock()
+	}
+}
+
+func (r *activityRegistry) snapshot() DebugInfo {
+	r.lock.Lock()
+	sessions := make([]*Session, 0, len(r.sessions))
+	for s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	runners := make([]*runnerEntry, 0, len(r.runners))
+	for entry := range r.runners {
+		runners = append(runners, entry)
+	}
+	r.lock.Unlock()
+
+	info := DebugInfo{Sessions: []DebugSession{}, Runners: []DebugRunner{}}
+	for _, s := range sessions {
+		info.Sessions = append(info.Sessions, s.info())
+	}
+	for _, entry := range runners {
+		status := entry.status.get()
+		runner := DebugRunner{
+			Kind:                entry.kind,
+			Key:                 entry.key,
+			State:               status.State,
+			SessionID:           status.SessionID,
+			Since:               status.Since,
+			ConsecutiveFailures: status.ConsecutiveFailures,
+		}
+		if status.LastError != nil {
+			runner.LastError = status.LastError.Error()
+		}
+		info.Runners = append(info.Runners, runner)
+	}
+
+	sort.Slice(info.Sessions, func(i, j int) bool {
+		return info.Sessions[i].ID < info.Sessions[j].ID
+	})
+	sort.Slice(info.Runners, func(i, j int) bool {
+		if info.Runners[i].Kind != info.Runners[j].Kind {
+			return info.Runners[i].Kind < info.Runners[j].Kind
+		}
+		return info.Runners[i].Key < info.Runners[j].Key
+	})
+
+	return info
+}
+
+// NewDebugHandler serves the sessions and runners of this process, to be
+// mounted on a debug server. It responds with JSON, or with an HTML page
+// when the client accepts text/html or asks for ?format=html.
+func NewDebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := activity.snapshot()
+
+		if r.URL.Query().Get("format") == "html" || strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			debugTemplate.Execute(w, info)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	})
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>locket</title></head>
+<body>
+<h1>Sessions</h1>
+<table border="1">
+<tr><th>ID</th><th>Name</th><th>TTL</th><th>Keys</th><th>Last renewal</th></tr>
+{{range .Sessions}}<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{.TTL}}</td><td>{{range .Keys}}{{.}}<br>{{end}}</td><td>{{.LastRenewal.Format "2006-01-02T15:04:05Z07:00"}}</td></tr>
+{{end}}</table>
+<h1>Runners</h1>
+<table border="1">
+<tr><th>Kind</th><th>Key</th><th>State</th><th>Session</th><th>Since</th><th>Failures</th><th>Last error</th></tr>
+{{range .Runners}}<tr><td>{{.Kind}}</td><td>{{.Key}}</td><td>{{.State}}</td><td>{{.SessionID}}</td><td>{{.Since.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{.ConsecutiveFailures}}</td><td>{{.LastError}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,113 @@
+package locket_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DebugHandler", func() {
+	var (
+		server      *httptest.Server
+		lockKey     string
+		lockProcess ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock := fakeclock.NewFakeClock(time.Now())
+		backend := locket.NewMemoryBackend(clock)
+		logger := lagertest.NewTestLogger("locket")
+		lockKey = locket.LockSchemaPath("debug-key")
+
+		lock := locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, time.Minute)
+		lockProcess = ginkgomon.Invoke(lock)
+
+		server = httptest.NewServer(locket.NewDebugHandler())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		ginkgomon.Kill(lockProcess)
+	})
+
+	fetch := func() locket.DebugInfo {
+		resp, err := http.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var info locket.DebugInfo
+		Expect(json.NewDecoder(resp.Body).Decode(&info)).To(Succeed())
+		return info
+	}
+
+	It("lists the running lock and the session holding its key", func() {
+		var runner locket.DebugRunner
+		for _, r := range fetch().Runners {
+			if r.Key == lockKey {
+				runner = r
+			}
+		}
+		Expect(runner.Kind).To(Equal("lock"))
+		Expect(runner.State).To(Equal(locket.StateHolding))
+		Expect(runner.SessionID).NotTo(BeEmpty())
+
+		var session locket.DebugSession
+		for _, s := range fetch().Sessions {
+			if s.ID == runner.SessionID {
+				session = s
+			}
+		}
+		Expect(session.Keys).To(Equal([]string{lockKey}))
+		Expect(session.TTL).To(Equal("1m0s"))
+		Expect(session.LastRenewal).NotTo(BeZero())
+	})
+
+	It("stops listing the lock once it stops running", func() {
+		ginkgomon.Interrupt(lockProcess)
+
+		for _, r := range fetch().Runners {
+			Expect(r.Key).NotTo(Equal(lockKey))
+		}
+	})
+
+	It("lists a running semaphore under its prefix", func() {
+		clock := fakeclock.NewFakeClock(time.Now())
+		backend := locket.NewMemoryBackend(clock)
+		prefix := locket.LockSchemaPath("debug-semaphore")
+
+		semaphore := locket.NewSemaphoreWithBackend(lagertest.NewTestLogger("locket"), backend, prefix, 1, []byte("a"), clock, time.Second, time.Minute)
+		process := ginkgomon.Invoke(semaphore)
+		defer ginkgomon.Kill(process)
+
+		var runner locket.DebugRunner
+		for _, r := range fetch().Runners {
+			if r.Key == prefix {
+				runner = r
+			}
+		}
+		Expect(runner.Kind).To(Equal("semaphore"))
+		Expect(runner.State).To(Equal(locket.StateHolding))
+	})
+
+	It("serves an HTML page to browsers", func() {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+	})
+})
@@ -1,22 +1,25 @@
 package locket
 
 import (
+	"context"
 	"os"
+	"sort"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/consuladapter"
 	"code.cloudfoundry.org/lager"
-	"github.com/hashicorp/consul/api"
 )
 
 type DisappearanceWatcher struct {
-	consulClient  consuladapter.Client
+	backend       Backend
 	keyPrefix     string
 	disappearChan chan []string
 
-	clock  clock.Clock
-	logger lager.Logger
+	clock   clock.Clock
+	options runnerOptions
+	logger  lager.Logger
+	status  *statusTracker
 }
 
 func NewDisappearanceWatcher(
@@ -24,103 +27,218 @@ func NewDisappearanceWatcher(
 	consulClient consuladapter.Client,
 	keyPrefix string,
 	clock clock.Clock,
+	opts ...Option,
+) (DisappearanceWatcher, <-chan []string) {
+	return NewDisappearanceWatcherWithBackend(logger, NewConsulBackend(consulClient), keyPrefix, clock, opts...)
+}
+
+func NewDisappearanceWatcherWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	keyPrefix string,
+	clock clock.Clock,
+	opts ...Option,
 ) (DisappearanceWatcher, <-chan []string) {
 	disappearChan := make(chan []string)
 	return DisappearanceWatcher{
-		consulClient:  consulClient,
+		backend:       backend,
 		keyPrefix:     keyPrefix,
 		disappearChan: disappearChan,
 
-		clock:  clock,
-		logger: logger,
+		clock:   clock,
+		options: newRunnerOptions(opts),
+		logger:  logger,
+		status:  newStatusTracker(clock),
 	}, disappearChan
 }
 
+// Status returns what the watcher is doing. It is safe to call from any
+// goroutine.
+func (d DisappearanceWatcher) Status() Status {
+	return d.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (d DisappearanceWatcher) StatusChanges() <-chan Status {
+	return d.status.changes
+}
+
 func (d DisappearanceWatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	logger := d.logger.Session("disappearance-watcher", lager.Data{"key-prefix": d.keyPrefix})
 	logger.Info("starting")
 	defer logger.Info("done")
 
-	stop := make(chan struct{})
-	WatchForDisappearancesUnder(logger, d.consulClient, d.disappearChan, stop, d.keyPrefix)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer activity.addRunner("disappearance-watcher", d.keyPrefix, d.status)()
+	defer d.status.enter(StateStopped, "")
+	disappearances := watchDisappearancesAsync(ctx, logger, d.backend, d.keyPrefix, d.clock, d.options, d.status)
+
+	defer close(d.disappearChan)
+
 	close(ready)
 
-	select {
-	case <-signals:
-		logger.Info("signalled")
+	for {
+		select {
+		case <-signals:
+			logger.Info("signalled")
+			return nil
+		case missing := <-disappearances:
+			select {
+			case d.disappearChan <- missing:
+			case <-signals:
+				logger.Info("signalled")
+				return nil
+			}
+		}
 	}
-
-	close(stop)
-	close(d.disappearChan)
-	return nil
 }
 
 const defaultWatchBlockDuration = 10 * time.Second
 
-var emptyBytes = []byte{}
+func WatchDisappearances(ctx context.Context, logger lager.Logger, client consuladapter.Client, prefix string, clock clock.Clock, opts ...Option) <-chan []string {
+	return WatchDisappearancesWithBackend(ctx, logger, NewConsulBackend(client), prefix, clock, opts...)
+}
 
-func WatchForDisappearancesUnder(logger lager.Logger, client consuladapter.Client, disappearanceChan chan []string, stop <-chan struct{}, prefix string) {
-	logger = logger.Session("watch-for-disappearances")
+// WatchDisappearancesWithBackend sends the keys under prefix that disappear
+// until ctx is done. The channel is closed once an in-flight blocking query
+// returns after that.
+func WatchDisappearancesWithBackend(ctx context.Context, logger lager.Logger, backend Backend, prefix string, clock clock.Clock, opts ...Option) <-chan []string {
+	return watchDisappearancesAsync(ctx, logger, backend, prefix, clock, newRunnerOptions(opts), nil)
+}
 
+func watchDisappearancesAsync(ctx context.Context, logger lager.Logger, backend Backend, prefix string, clock clock.Clock, options runnerOptions, status *statusTracker) <-chan []string {
+	disappearanceChan := make(chan []string)
 	go func() {
-		logger.Info("starting")
-		defer logger.Info("finished")
+		defer close(disappearanceChan)
+		watchDisappearances(logger.Session("watch-for-disappearances"), backend, clock, options, status, disappearanceChan, ctx.Done(), prefix)
+	}()
 
-		keys := keySet{}
+	return disappearanceChan
+}
 
-		queryOpts := &api.QueryOptions{
-			WaitIndex: 0,
-			WaitTime:  defaultWatchBlockDuration,
+func WatchForDisappearancesUnder(logger lager.Logger, client consuladapter.Client, disappearanceChan chan []string, stop <-chan struct{}, prefix string, opts ...Option) {
+	go watchDisappearances(logger.Session("watch-for-disappearances"), NewConsulBackend(client), clock.NewClock(), newRunnerOptions(opts), nil, disappearanceChan, stop, prefix)
+}
+
+const disappearanceWatchRetryInterval = 1 * time.Second
+
+func watchDisappearances(logger lager.Logger, backend Backend, clock clock.Clock, options runnerOptions, status *statusTracker, disappearanceChan chan<- []string, stop <-chan struct{}, prefix string) {
+	metrics := options.metricsOr(NoopMetricsEmitter{})
+
+	watchKeySets(logger, backend, clock, options, status, stop, prefix, func(previous, current keySet) bool {
+		missing := difference(previous, current)
+		if len(missing) == 0 {
+			return true
 		}
 
-		for {
-			newPairs, queryMeta, err := client.KV().List(prefix, queryOpts)
-
-			if err != nil {
-				logger.Error("list-failed", err)
-				select {
-				case <-stop:
-					return
-				case <-time.After(1 * time.Second):
-				}
-				queryOpts.WaitIndex = 0
-				continue
+		metrics.DisappearancesObserved(prefix, missing)
+		select {
+		case disappearanceChan <- missing:
+			return true
+		case <-stop:
+			return false
+		}
+	})
+}
+
+// KeyChange is the keys under a prefix that became held, and those that
+// stopped being held, since the previous change.
+type KeyChange struct {
+	Appeared    []string
+	Disappeared []string
+}
+
+func WatchKeys(ctx context.Context, logger lager.Logger, client consuladapter.Client, prefix string, clock clock.Clock, opts ...Option) <-chan KeyChange {
+	return WatchKeysWithBackend(ctx, logger, NewConsulBackend(client), prefix, clock, opts...)
+}
+
+// WatchKeysWithBackend sends the keys under prefix that are held when it
+// starts as appeared, and then every change to them, until ctx is done.
+func WatchKeysWithBackend(ctx context.Context, logger lager.Logger, backend Backend, prefix string, clock clock.Clock, opts ...Option) <-chan KeyChange {
+	changes := make(chan KeyChange)
+	options := newRunnerOptions(opts)
+
+	go func() {
+		defer close(changes)
+		watchKeySets(logger.Session("watch-keys"), backend, clock, options, nil, ctx.Done(), prefix, func(previous, current keySet) bool {
+			change := KeyChange{
+				Appeared:    difference(current, previous),
+				Disappeared: difference(previous, current),
+			}
+			if len(change.Appeared) == 0 && len(change.Disappeared) == 0 {
+				return true
 			}
 
+			sort.Strings(change.Appeared)
+			sort.Strings(change.Disappeared)
 			select {
-			case <-stop:
-				return
-			default:
+			case changes <- change:
+				return true
+			case <-ctx.Done():
+				return false
 			}
+		})
+	}()
 
-			queryOpts.WaitIndex = queryMeta.LastIndex
+	return changes
+}
 
-			if newPairs == nil {
-				// key not found
-				_, err = client.KV().Put(&api.KVPair{Key: prefix, Value: emptyBytes}, nil)
-				if err != nil {
-					logger.Error("put-failed", err)
-					continue
-				}
-			}
+// watchKeySets calls changed with the held keys under prefix every time a
+// blocking query returns, until stop is closed or changed returns false.
+func watchKeySets(logger lager.Logger, backend Backend, clock clock.Clock, options runnerOptions, status *statusTracker, stop <-chan struct{}, prefix string, changed func(previous, current keySet) bool) {
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	keys := keySet{}
+	retry := newRetrier(options.backoffOr(disappearanceWatchRetryInterval))
+	metrics := options.metricsOr(NoopMetricsEmitter{})
 
-			newKeys := newKeySet(newPairs)
-			if missing := difference(keys, newKeys); len(missing) > 0 {
-				select {
-				case disappearanceChan <- missing:
-				case <-stop:
-					return
-				}
+	var waitIndex uint64
+
+	status.enter(StateWatching, "")
+
+	for {
+		newPairs, lastIndex, err := backend.ListPrefix(prefix, waitIndex, defaultWatchBlockDuration)
+
+		if err != nil {
+			logger.Error("list-failed", err)
+			metrics.WatchFailed(prefix, err)
+			status.fail(StateRetrying, err)
+			timer := clock.NewTimer(retry.next())
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C():
 			}
+			waitIndex = 0
+			continue
+		}
+		retry.reset()
+		status.succeed(StateWatching, "")
 
-			keys = newKeys
+		select {
+		case <-stop:
+			return
+		default:
 		}
-	}()
+
+		waitIndex = lastIndex
+
+		newKeys := newKeySet(newPairs)
+		if !changed(keys, newKeys) {
+			return
+		}
+
+		keys = newKeys
+	}
 }
 
 type keySet map[string]struct{}
 
-func newKeySet(keyPairs api.KVPairs) keySet {
+func newKeySet(keyPairs []KeyValue) keySet {
 	newKeySet := keySet{}
 	for _, kvPair := range keyPairs {
 		if kvPair.Session != "" {
@@ -0,0 +1,122 @@
+package locket
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+var (
+	ErrInvalidSession    = errors.New("invalid session")
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrInvalidKey        = errors.New("invalid key")
+	ErrKeyConflict       = errors.New("key conflict")
+	ErrAgentUnreachable  = errors.New("agent unreachable")
+	ErrLeaderUnavailable = errors.New("leader unavailable")
+	ErrTimeout           = errors.New("timeout")
+	ErrCancelled         = errors.New("cancelled")
+	ErrBadRequest        = errors.New("bad request")
+)
+
+// BackendError is a backend failure classified as one of the errors above.
+// errors.Is matches it against Kind, and Unwrap gives back the original error.
+type BackendError struct {
+	Kind error
+	Err  error
+}
+
+func (e *BackendError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *BackendError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *BackendError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether trying again later may succeed. Errors that
+// are not classified are assumed to be transient. Only a cancellation by the
+// caller is fatal: a session that ends under an acquire is ErrInvalidSession.
+func IsRetryable(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrPermissionDenied) &&
+		!errors.Is(err, ErrInvalidKey) &&
+		!errors.Is(err, ErrCancelled) &&
+		!errors.Is(err, context.Canceled)
+}
+
+var consulResponseCode = regexp.MustCompile(`Unexpected response code: (\d+)`)
+
+func convertError(err error) error {
+	if err == nil {
+		return err
+	}
+
+	if kind := classifyConsulError(err); kind != nil {
+		return &BackendError{Kind: kind, Err: err}
+	}
+
+	return err
+}
+
+func classifyConsulError(err error) error {
+	switch err {
+	case api.ErrLockConflict, api.ErrLockInUse:
+		return ErrKeyConflict
+	case api.ErrSessionExpired:
+		return ErrInvalidSession
+	case context.Canceled:
+		return ErrCancelled
+	case context.DeadlineExceeded:
+		return ErrTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+		return ErrAgentUnreachable
+	}
+
+	message := err.Error()
+	match := consulResponseCode.FindStringSubmatch(message)
+	if match == nil {
+		return nil
+	}
+	code, _ := strconv.Atoi(match[1])
+
+	switch {
+	case code == 403, strings.Contains(message, "Permission denied"), strings.Contains(message, "ACL not found"):
+		return ErrPermissionDenied
+	case strings.Contains(message, "Invalid session"), strings.Contains(message, "invalid session"):
+		return ErrInvalidSession
+	case strings.Contains(message, "No cluster leader"):
+		return ErrLeaderUnavailable
+	case code == 400 && invalidKeyMessage(message):
+		return ErrInvalidKey
+	case code == 400:
+		return ErrBadRequest
+	case code == 409:
+		return ErrKeyConflict
+	case code == 504:
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// invalidKeyMessage picks out the 400s Consul returns for the key itself;
+// other bad requests may succeed once the cluster settles, so stay retryable.
+func invalidKeyMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "invalid key") || strings.Contains(message, "missing key")
+}
@@ -0,0 +1,104 @@
+package locket_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/consuladapter/fakes"
+	"code.cloudfoundry.org/locket"
+	"github.com/hashicorp/consul/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Consul errors", func() {
+	var (
+		kv      *fakes.FakeKV
+		session *fakes.FakeSession
+		client  *fakes.FakeClient
+		backend locket.Backend
+	)
+
+	BeforeEach(func() {
+		var components *fakes.FakeClientComponents
+		client, components = fakes.NewFakeClient()
+		kv = components.KV
+		session = components.Session
+		backend = locket.NewConsulBackend(client)
+	})
+
+	getKeyError := func(consulErr error) error {
+		kv.GetReturns(nil, nil, consulErr)
+		_, err := backend.GetKey("some-key")
+		Expect(err).To(HaveOccurred())
+		return err
+	}
+
+	It("recognises an invalid session", func() {
+		err := getKeyError(errors.New("Unexpected response code: 500 (Invalid session)"))
+		Expect(errors.Is(err, locket.ErrInvalidSession)).To(BeTrue())
+		Expect(locket.IsRetryable(err)).To(BeTrue())
+	})
+
+	It("reports a session that expired before it was renewed as invalid", func() {
+		session.RenewReturns(nil, nil, nil)
+
+		err := backend.RenewSession("some-session", 20*time.Millisecond, make(chan struct{}), nil)
+		Expect(errors.Is(err, locket.ErrInvalidSession)).To(BeTrue())
+		Expect(locket.IsRetryable(err)).To(BeTrue())
+	})
+
+	It("recognises a permission denial as fatal", func() {
+		err := getKeyError(errors.New("Unexpected response code: 403 (Permission denied)"))
+		Expect(errors.Is(err, locket.ErrPermissionDenied)).To(BeTrue())
+		Expect(locket.IsRetryable(err)).To(BeFalse())
+
+		err = getKeyError(errors.New("Unexpected response code: 500 (rpc error: ACL not found)"))
+		Expect(errors.Is(err, locket.ErrPermissionDenied)).To(BeTrue())
+	})
+
+	It("recognises a missing leader", func() {
+		err := getKeyError(errors.New("Unexpected response code: 500 (No cluster leader)"))
+		Expect(errors.Is(err, locket.ErrLeaderUnavailable)).To(BeTrue())
+		Expect(locket.IsRetryable(err)).To(BeTrue())
+	})
+
+	It("recognises an unreachable agent", func() {
+		opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		err := getKeyError(fmt.Errorf("Get http://127.0.0.1:8500/v1/kv/some-key: %w", opErr))
+		Expect(errors.Is(err, locket.ErrAgentUnreachable)).To(BeTrue())
+
+		var unwrapped *net.OpError
+		Expect(errors.As(err, &unwrapped)).To(BeTrue())
+		Expect(unwrapped).To(Equal(opErr))
+	})
+
+	It("recognises an invalid key as fatal", func() {
+		err := getKeyError(errors.New("Unexpected response code: 400 (Invalid key name)"))
+		Expect(errors.Is(err, locket.ErrInvalidKey)).To(BeTrue())
+		Expect(locket.IsRetryable(err)).To(BeFalse())
+	})
+
+	It("keeps other bad requests retryable", func() {
+		consulErr := errors.New("Unexpected response code: 400 (Request body too large)")
+		err := getKeyError(consulErr)
+		Expect(errors.Is(err, locket.ErrInvalidKey)).To(BeFalse())
+		Expect(errors.Is(err, locket.ErrBadRequest)).To(BeTrue())
+		Expect(errors.Unwrap(err)).To(Equal(consulErr))
+		Expect(locket.IsRetryable(err)).To(BeTrue())
+	})
+
+	It("recognises a key conflict", func() {
+		client.LockOptsReturns(nil, api.ErrLockConflict)
+		_, _, err := backend.AcquireKey("session-id", "some-key", []byte("value"), locket.LockType, nil)
+		Expect(errors.Is(err, locket.ErrKeyConflict)).To(BeTrue())
+	})
+
+	It("passes other errors through", func() {
+		consulErr := errors.New("something else")
+		Expect(getKeyError(consulErr)).To(Equal(consulErr))
+	})
+})
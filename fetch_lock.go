@@ -0,0 +1,111 @@
+package locket
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/consuladapter"
+)
+
+type LockNotFoundError string
+
+func (e LockNotFoundError) Error() string {
+	return fmt.Sprintf("Lock '%s' is not held", string(e))
+}
+
+// LockHolder describes the session currently holding a lock key. Value is
+// unwrapped from its owner envelope when there is one, and Owner is nil for
+// raw values. AcquiredAt is zero when neither the backend nor the owner
+// envelope records it.
+type LockHolder struct {
+	Key         string
+	Value       []byte
+	Owner       *OwnerInfo
+	SessionID   string
+	SessionName string
+	Node        string
+	AcquiredAt  time.Time
+}
+
+func FetchLock(client consuladapter.Client, key string) (*LockHolder, error) {
+	return FetchLockWithBackend(NewConsulBackend(client), key)
+}
+
+// FetchLockWithBackend returns the current holder of key, or a
+// LockNotFoundError when nobody holds it.
+func FetchLockWithBackend(backend Backend, key string) (*LockHolder, error) {
+	kv, err := backend.GetKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if kv == nil || kv.Session == "" {
+		return nil, LockNotFoundError(key)
+	}
+
+	return newLockHolder(backend, *kv)
+}
+
+func FetchLocks(client consuladapter.Client, prefix string) ([]*LockHolder, error) {
+	return FetchLocksWithBackend(NewConsulBackend(client), prefix)
+}
+
+// FetchLocksWithBackend returns the holders of the keys under prefix, skipping
+// the keys that nobody holds.
+func FetchLocksWithBackend(backend Backend, prefix string) ([]*LockHolder, error) {
+	kvs, _, err := backend.ListPrefix(prefix, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := []*LockHolder{}
+	for _, kv := range kvs {
+		if kv.Session == "" {
+			continue
+		}
+
+		holder, err := newLockHolder(backend, kv)
+		if _, ok := err.(LockNotFoundError); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		holders = append(holders, holder)
+	}
+
+	sort.Slice(holders, func(i, j int) bool {
+		return holders[i].Key < holders[j].Key
+	})
+
+	return holders, nil
+}
+
+func newLockHolder(backend Backend, kv KeyValue) (*LockHolder, error) {
+	session, err := backend.SessionInfo(kv.Session)
+	if errors.Is(err, ErrInvalidSession) {
+		// the holder went away since the key was read
+		return nil, LockNotFoundError(kv.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	owner, value := DecodeOwnerValue(kv.Value)
+
+	acquiredAt := kv.AcquiredAt
+	if acquiredAt.IsZero() && owner != nil {
+		acquiredAt = owner.AcquiredAt
+	}
+
+	return &LockHolder{
+		Key:         kv.Key,
+		Value:       value,
+		Owner:       owner,
+		SessionID:   kv.Session,
+		SessionName: session.Name,
+		Node:        session.Node,
+		AcquiredAt:  acquiredAt,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/locket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FetchLock", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		lockKey string
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		lockKey = locket.LockSchemaPath("some-key")
+	})
+
+	It("returns a LockNotFoundError when nobody holds the key", func() {
+		_, err := locket.FetchLockWithBackend(backend, lockKey)
+		Expect(err).To(Equal(locket.LockNotFoundError(lockKey)))
+	})
+
+	Context("when the key is held", func() {
+		var (
+			sessionID  string
+			acquiredAt time.Time
+		)
+
+		BeforeEach(func() {
+			var err error
+			sessionID, err = backend.CreateSession("some-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			acquiredAt = clock.Now()
+			_, _, err = backend.AcquireKey(sessionID, lockKey, []byte("some-value"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the holder", func() {
+			holder, err := locket.FetchLockWithBackend(backend, lockKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*holder).To(Equal(locket.LockHolder{
+				Key:         lockKey,
+				Value:       []byte("some-value"),
+				SessionID:   sessionID,
+				SessionName: "some-session",
+				AcquiredAt:  acquiredAt,
+			}))
+		})
+
+		It("returns a LockNotFoundError once the holder's session is gone", func() {
+			Expect(backend.DestroySession(sessionID)).To(Succeed())
+
+			_, err := locket.FetchLockWithBackend(backend, lockKey)
+			Expect(err).To(Equal(locket.LockNotFoundError(lockKey)))
+		})
+	})
+
+	Describe("FetchLocksWithBackend", func() {
+		It("returns the holders of the held keys under the prefix, by key", func() {
+			sessionID, err := backend.CreateSession("some-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, key := range []string{"b", "a"} {
+				_, _, err = backend.AcquireKey(sessionID, locket.LockSchemaPath(key), []byte(key), locket.LockType, nil)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, _, err = backend.AcquireKey(sessionID, "v1/elsewhere", []byte("c"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			holders, err := locket.FetchLocksWithBackend(backend, locket.LockSchemaRoot)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holders).To(HaveLen(2))
+			Expect(holders[0].Key).To(Equal(locket.LockSchemaPath("a")))
+			Expect(holders[0].SessionName).To(Equal("some-session"))
+			Expect(holders[1].Key).To(Equal(locket.LockSchemaPath("b")))
+		})
+
+		It("skips keys whose holder went away after they were listed", func() {
+			sessionID, err := backend.CreateSession("some-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = backend.AcquireKey(sessionID, lockKey, []byte("a"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			stale := staleListingBackend{Backend: backend, kvs: []locket.KeyValue{
+				{Key: locket.LockSchemaPath("gone"), Value: []byte("b"), Session: "gone-session"},
+				{Key: lockKey, Value: []byte("a"), Session: sessionID},
+			}}
+
+			holders, err := locket.FetchLocksWithBackend(stale, locket.LockSchemaRoot)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holders).To(HaveLen(1))
+			Expect(holders[0].Key).To(Equal(lockKey))
+		})
+	})
+})
+
+// staleListingBackend lists keys as they were before their holders went away.
+type staleListingBackend struct {
+	locket.Backend
+	kvs []locket.KeyValue
+}
+
+func (b staleListingBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]locket.KeyValue, uint64, error) {
+	return b.kvs, 1, nil
+}
@@ -0,0 +1,133 @@
+package locket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"github.com/hashicorp/consul/api"
+)
+
+const AuditSchemaRoot = "v1/audit"
+
+// ErrForceReleaseUnsupported is returned by ForceReleaseWithBackend for a
+// LockStore backend, which has nowhere to record the revocation. The locket
+// server has no call to release a lock by key, so locks kept in its store
+// cannot be forcibly released.
+var ErrForceReleaseUnsupported = errors.New("force release is only supported on Consul")
+
+// RevocationSchemaPath is where the revocation of key from a session is
+// recorded.
+func RevocationSchemaPath(key, sessionID string) string {
+	return path.Join(AuditSchemaRoot, "revocations", key, sessionID)
+}
+
+// Revocation is the audit entry of a lock forcibly taken from its holder.
+type Revocation struct {
+	Key            string    `json:"key"`
+	SessionID      string    `json:"session_id"`
+	SessionName    string    `json:"session_name,omitempty"`
+	Node           string    `json:"node,omitempty"`
+	Operator       string    `json:"operator"`
+	Reason         string    `json:"reason"`
+	DestroySession bool      `json:"destroy_session"`
+	RevokedAt      time.Time `json:"revoked_at"`
+}
+
+// ForceReleaseRequest says which lock to take from its holder, and who is
+// taking it and why. DestroySession invalidates the holder's session, and
+// with it every other key the session holds, instead of only releasing Key.
+type ForceReleaseRequest struct {
+	Key            string
+	Operator       string
+	Reason         string
+	DestroySession bool
+}
+
+// ForceRelease takes a lock held in Consul from its holder; see
+// ForceReleaseWithBackend.
+func ForceRelease(client *api.Client, clock clock.Clock, request ForceReleaseRequest) (*Revocation, error) {
+	return ForceReleaseWithBackend(NewConsulAPIBackend(client), clock, request)
+}
+
+// ForceReleaseWithBackend takes a lock from a holder that is wedged but keeps
+// its session alive. It records the revocation before releasing the lock, so
+// that the evicted Lock sees ErrLockRevoked, and removes it again if the
+// release fails. It returns a LockNotFoundError when nobody holds the key.
+// Only the Consul and memory backends can record revocations: a LockStore
+// backend returns ErrForceReleaseUnsupported and leaves the lock with its
+// holder.
+func ForceReleaseWithBackend(backend Backend, clock clock.Clock, request ForceReleaseRequest) (*Revocation, error) {
+	if request.Operator == "" || request.Reason == "" {
+		return nil, errors.New("force release needs an operator and a reason")
+	}
+
+	holder, err := FetchLockWithBackend(backend, request.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	revocation := &Revocation{
+		Key:            request.Key,
+		SessionID:      holder.SessionID,
+		SessionName:    holder.SessionName,
+		Node:           holder.Node,
+		Operator:       request.Operator,
+		Reason:         request.Reason,
+		DestroySession: request.DestroySession,
+		RevokedAt:      clock.Now(),
+	}
+
+	value, err := json.Marshal(revocation)
+	if err != nil {
+		return nil, err
+	}
+
+	revocationKey := RevocationSchemaPath(request.Key, holder.SessionID)
+	err = backend.PutKey(revocationKey, value)
+	if errors.Is(err, errUnownedKeys) {
+		return nil, ErrForceReleaseUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if request.DestroySession {
+		err = backend.DestroySession(holder.SessionID)
+	} else {
+		err = backend.ReleaseKey(holder.SessionID, request.Key)
+	}
+	if err != nil {
+		if deleteErr := backend.DeleteKey(revocationKey); deleteErr != nil {
+			return nil, fmt.Errorf("%w (could not remove revocation %s: %v)", err, revocationKey, deleteErr)
+		}
+		return nil, err
+	}
+
+	return revocation, nil
+}
+
+func FetchRevocation(client consuladapter.Client, key, sessionID string) (*Revocation, error) {
+	return FetchRevocationWithBackend(NewConsulBackend(client), key, sessionID)
+}
+
+// FetchRevocationWithBackend returns nil when key was not revoked from the
+// session.
+func FetchRevocationWithBackend(backend Backend, key, sessionID string) (*Revocation, error) {
+	kv, err := backend.GetKey(RevocationSchemaPath(key, sessionID))
+	if err != nil || kv == nil {
+		return nil, err
+	}
+
+	var revocation Revocation
+	err = json.Unmarshal(kv.Value, &revocation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &revocation, nil
+}
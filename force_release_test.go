@@ -0,0 +1,172 @@
+package locket_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ForceRelease", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+		lockKey string
+		lock    locket.Lock
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		lockKey = locket.LockSchemaPath("some-key")
+		lock = locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+	})
+
+	request := func(destroySession bool) locket.ForceReleaseRequest {
+		return locket.ForceReleaseRequest{
+			Key:            lockKey,
+			Operator:       "some-operator",
+			Reason:         "wedged",
+			DestroySession: destroySession,
+		}
+	}
+
+	It("needs an operator and a reason", func() {
+		_, err := locket.ForceReleaseWithBackend(backend, clock, locket.ForceReleaseRequest{Key: lockKey})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns a LockNotFoundError when nobody holds the key", func() {
+		_, err := locket.ForceReleaseWithBackend(backend, clock, request(false))
+		Expect(err).To(Equal(locket.LockNotFoundError(lockKey)))
+	})
+
+	It("releases the key and records who did it and why", func() {
+		handle, err := lock.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		defer handle.Release()
+		sessionID := lock.Status().SessionID
+
+		revocation, err := locket.ForceReleaseWithBackend(backend, clock, request(false))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revocation.Key).To(Equal(lockKey))
+		Expect(revocation.SessionID).To(Equal(sessionID))
+		Expect(revocation.Operator).To(Equal("some-operator"))
+		Expect(revocation.Reason).To(Equal("wedged"))
+		Expect(revocation.RevokedAt).To(Equal(clock.Now()))
+
+		recorded, err := locket.FetchRevocationWithBackend(backend, lockKey, sessionID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorded.Operator).To(Equal("some-operator"))
+		Expect(recorded.Reason).To(Equal("wedged"))
+		Expect(recorded.RevokedAt.Equal(revocation.RevokedAt)).To(BeTrue())
+		Eventually(handle.Lost()).Should(Receive(Equal(locket.ErrLockRevoked)))
+		_, err = locket.FetchLockWithBackend(backend, lockKey)
+		Expect(err).To(Equal(locket.LockNotFoundError(lockKey)))
+	})
+
+	It("destroys the holder's session when asked to", func() {
+		handle, err := lock.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		defer handle.Release()
+		sessionID := lock.Status().SessionID
+
+		_, err = locket.ForceReleaseWithBackend(backend, clock, request(true))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(handle.Lost()).Should(Receive(Equal(locket.ErrLockRevoked)))
+		_, err = backend.SessionInfo(sessionID)
+		Expect(err).To(Equal(locket.ErrInvalidSession))
+	})
+
+	It("makes the evicted runner exit with ErrLockRevoked", func() {
+		process := ginkgomon.Invoke(lock)
+		defer ginkgomon.Kill(process)
+
+		_, err := locket.ForceReleaseWithBackend(backend, clock, request(false))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(process.Wait()).Should(Receive(Equal(locket.ErrLockRevoked)))
+		Expect(logger).To(Say("lock-revoked"))
+	})
+
+	It("makes the evicted runner exit with ErrLockRevoked even when it stands by", func() {
+		changes := make(chan locket.LeadershipChange)
+		lock = locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl, locket.WithStandbyAfterLoss(changes))
+		process := ifrit.Background(lock)
+		defer ginkgomon.Kill(process)
+		Eventually(changes).Should(Receive(HaveField("Held", BeTrue())))
+
+		_, err := locket.ForceReleaseWithBackend(backend, clock, request(false))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(process.Wait()).Should(Receive(Equal(locket.ErrLockRevoked)))
+	})
+
+	It("makes a standby LeaderRunner exit with ErrLockRevoked", func() {
+		child := ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			close(ready)
+			<-signals
+			return nil
+		})
+		process := ifrit.Background(locket.NewLeaderRunner(logger, lock, child, true))
+		defer ginkgomon.Kill(process)
+		Eventually(process.Ready()).Should(BeClosed())
+
+		_, err := locket.ForceReleaseWithBackend(backend, clock, request(false))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(process.Wait()).Should(Receive(Equal(locket.ErrLockRevoked)))
+	})
+
+	It("removes the revocation again when the release fails", func() {
+		handle, err := lock.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		defer handle.Release()
+		sessionID := lock.Status().SessionID
+
+		releaseErr := errors.New("release failed")
+		_, err = locket.ForceReleaseWithBackend(failingReleaseBackend{backend, releaseErr}, clock, request(false))
+		Expect(err).To(Equal(releaseErr))
+
+		revocation, err := locket.FetchRevocationWithBackend(backend, lockKey, sessionID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revocation).To(BeNil())
+		Consistently(handle.Lost()).ShouldNot(Receive())
+	})
+
+	It("reports a loss that was not forced as lost", func() {
+		handle, err := lock.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		defer handle.Release()
+
+		Expect(backend.DestroySession(lock.Status().SessionID)).To(Succeed())
+
+		var lostErr error
+		Eventually(handle.Lost()).Should(Receive(&lostErr))
+		Expect(lostErr).NotTo(Equal(locket.ErrLockRevoked))
+	})
+})
+
+type failingReleaseBackend struct {
+	locket.Backend
+	err error
+}
+
+func (b failingReleaseBackend) ReleaseKey(sessionID, key string) error {
+	return b.err
+}
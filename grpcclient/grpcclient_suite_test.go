@@ -0,0 +1,13 @@
+package grpcclient_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGRPCClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GRPC Client Suite")
+}
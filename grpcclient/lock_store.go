@@ -0,0 +1,110 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const DefaultRequestTimeout = 10 * time.Second
+
+// Dial connects to a locket server. The connection is plaintext when
+// tlsConfig is nil.
+func Dial(address string, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	return grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+}
+
+type lockStore struct {
+	client         models.LocketClient
+	requestTimeout time.Duration
+}
+
+// NewLockStore exposes a locket server as a locket.LockStore, so that Lock and
+// Presence can run against it through locket.NewLockStoreBackend.
+func NewLockStore(client models.LocketClient, requestTimeout time.Duration) locket.LockStore {
+	return &lockStore{
+		client:         client,
+		requestTimeout: requestTimeout,
+	}
+}
+
+func (s *lockStore) Lock(resource locket.Resource, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+
+	response, err := s.client.Lock(ctx, &models.LockRequest{
+		Resource:     models.NewResource(resource),
+		TtlInSeconds: int64((ttl + time.Second - 1) / time.Second),
+	})
+	if err != nil {
+		return 0, models.FromStatusError(err)
+	}
+
+	return response.GetIndex(), nil
+}
+
+func (s *lockStore) LockAll(resources []locket.Resource, ttl time.Duration) ([]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+
+	request := &models.LockAllRequest{TtlInSeconds: int64((ttl + time.Second - 1) / time.Second)}
+	for _, resource := range resources {
+		request.Resources = append(request.Resources, models.NewResource(resource))
+	}
+
+	response, err := s.client.LockAll(ctx, request)
+	if err != nil {
+		return nil, models.FromStatusError(err)
+	}
+
+	return response.GetIndexes(), nil
+}
+
+func (s *lockStore) Release(resource locket.Resource) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+
+	_, err := s.client.Release(ctx, &models.ReleaseRequest{Resource: models.NewResource(resource)})
+	return models.FromStatusError(err)
+}
+
+func (s *lockStore) Fetch(key string) (*locket.Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+
+	response, err := s.client.Fetch(ctx, &models.FetchRequest{Key: key})
+	if err != nil {
+		return nil, models.FromStatusError(err)
+	}
+
+	resource := response.GetResource().LocketResource()
+	return &resource, nil
+}
+
+func (s *lockStore) FetchAll(lockType string) ([]locket.Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+
+	response, err := s.client.FetchAll(ctx, &models.FetchAllRequest{Type: lockType})
+	if err != nil {
+		return nil, models.FromStatusError(err)
+	}
+
+	resources := make([]locket.Resource, 0, len(response.GetResources()))
+	for _, r := range response.GetResources() {
+		resources = append(resources, r.LocketResource())
+	}
+
+	return resources, nil
+}
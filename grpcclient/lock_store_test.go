@@ -0,0 +1,93 @@
+package grpcclient_test
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/db"
+	"code.cloudfoundry.org/locket/grpcclient"
+	"code.cloudfoundry.org/locket/grpcserver"
+	"code.cloudfoundry.org/locket/handlers"
+	"code.cloudfoundry.org/locket/models"
+	"github.com/onsi/ginkgo/config"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"google.golang.org/grpc"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LockStore", func() {
+	var (
+		sqlConn       *sql.DB
+		serverProcess ifrit.Process
+		conn          *grpc.ClientConn
+		store         locket.LockStore
+		logger        *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		var err error
+		sqlConn, err = sql.Open(db.SQLite, ":memory:")
+		Expect(err).NotTo(HaveOccurred())
+		sqlConn.SetMaxOpenConns(1)
+
+		sqlDB := db.NewSQLDB(sqlConn, db.SQLite)
+		Expect(sqlDB.CreateLockTable()).To(Succeed())
+
+		logger = lagertest.NewTestLogger("locket")
+		address := fmt.Sprintf("127.0.0.1:%d", 18891+config.GinkgoConfig.ParallelNode)
+		serverProcess = ginkgomon.Invoke(grpcserver.NewGRPCServer(logger, address, nil, handlers.NewLocketHandler(logger, sqlDB)))
+
+		conn, err = grpcclient.Dial(address, nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = grpcclient.NewLockStore(models.NewLocketClient(conn), grpcclient.DefaultRequestTimeout)
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		ginkgomon.Interrupt(serverProcess)
+		sqlConn.Close()
+	})
+
+	It("locks, fetches and releases resources through the server", func() {
+		resource := locket.Resource{Key: "some-key", Owner: "some-owner", Value: []byte("value"), Type: locket.LockType}
+		index, err := store.Lock(resource, 10*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index).To(BeNumerically(">", 0))
+
+		fetched, err := store.Fetch("some-key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*fetched).To(Equal(resource))
+
+		other := resource
+		other.Owner = "other-owner"
+		_, err = store.Lock(other, 10*time.Second)
+		Expect(err).To(Equal(locket.ErrLockCollision))
+
+		Expect(store.Release(resource)).To(Succeed())
+		_, err = store.Fetch("some-key")
+		Expect(err).To(Equal(locket.ErrResourceNotFound))
+	})
+
+	It("runs a Lock on top of the server", func() {
+		backend := locket.NewLockStoreBackend(store, clock.NewClock(), 50*time.Millisecond)
+		lockKey := locket.LockSchemaPath("some-lock")
+
+		lockProcess := ifrit.Background(locket.NewLockWithBackend(logger, backend, lockKey, []byte("value"), clock.NewClock(), time.Second, 10*time.Second))
+		defer ginkgomon.Kill(lockProcess)
+		Eventually(lockProcess.Ready()).Should(BeClosed())
+
+		resources, err := store.FetchAll(locket.LockType)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].Key).To(Equal(lockKey))
+	})
+})
@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket/models"
+	"github.com/tedsuo/ifrit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type grpcServer struct {
+	logger        lager.Logger
+	listenAddress string
+	tlsConfig     *tls.Config
+	handler       models.LocketServer
+}
+
+// NewGRPCServer serves the Locket API on listenAddress. The connection is
+// plaintext when tlsConfig is nil.
+func NewGRPCServer(logger lager.Logger, listenAddress string, tlsConfig *tls.Config, handler models.LocketServer) ifrit.Runner {
+	return &grpcServer{
+		logger:        logger,
+		listenAddress: listenAddress,
+		tlsConfig:     tlsConfig,
+		handler:       handler,
+	}
+}
+
+func (s *grpcServer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := s.logger.Session("grpc-server", lager.Data{"listen-address": s.listenAddress})
+	logger.Info("starting")
+	defer logger.Info("done")
+
+	listener, err := net.Listen("tcp", s.listenAddress)
+	if err != nil {
+		logger.Error("failed-to-listen", err)
+		return err
+	}
+
+	var opts []grpc.ServerOption
+	if s.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	models.RegisterLocketServer(server, s.handler)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	close(ready)
+	logger.Info("started")
+
+	select {
+	case sig := <-signals:
+		logger.Info("signalled", lager.Data{"received-signal": sig})
+		server.GracefulStop()
+		return nil
+	case err := <-errCh:
+		logger.Error("serve-failed", err)
+		return err
+	}
+}
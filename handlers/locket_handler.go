@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type locketHandler struct {
+	models.UnimplementedLocketServer
+
+	logger lager.Logger
+	store  locket.LockStore
+}
+
+func NewLocketHandler(logger lager.Logger, store locket.LockStore) models.LocketServer {
+	return &locketHandler{
+		logger: logger,
+		store:  store,
+	}
+}
+
+func (h *locketHandler) Lock(ctx context.Context, req *models.LockRequest) (*models.LockResponse, error) {
+	logger := h.logger.Session("lock", lager.Data{"request": req})
+	logger.Debug("started")
+	defer logger.Debug("finished")
+
+	if req.GetResource() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing resource")
+	}
+	if req.GetTtlInSeconds() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl must be positive")
+	}
+
+	index, err := h.store.Lock(req.GetResource().LocketResource(), time.Duration(req.GetTtlInSeconds())*time.Second)
+	if err != nil {
+		if err != locket.ErrLockCollision {
+			logger.Error("failed-locking-resource", err)
+		}
+		return nil, models.ToStatusError(err)
+	}
+
+	return &models.LockResponse{Index: index}, nil
+}
+
+func (h *locketHandler) LockAll(ctx context.Context, req *models.LockAllRequest) (*models.LockAllResponse, error) {
+	logger := h.logger.Session("lock-all", lager.Data{"request": req})
+	logger.Debug("started")
+	defer logger.Debug("finished")
+
+	if len(req.GetResources()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing resources")
+	}
+	if req.GetTtlInSeconds() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl must be positive")
+	}
+
+	resources := make([]locket.Resource, 0, len(req.GetResources()))
+	for _, r := range req.GetResources() {
+		resources = append(resources, r.LocketResource())
+	}
+
+	indexes, err := h.store.LockAll(resources, time.Duration(req.GetTtlInSeconds())*time.Second)
+	if err != nil {
+		if err != locket.ErrLockCollision {
+			logger.Error("failed-locking-resources", err)
+		}
+		return nil, models.ToStatusError(err)
+	}
+
+	return &models.LockAllResponse{Indexes: indexes}, nil
+}
+
+func (h *locketHandler) Release(ctx context.Context, req *models.ReleaseRequest) (*models.ReleaseResponse, error) {
+	logger := h.logger.Session("release", lager.Data{"request": req})
+	logger.Debug("started")
+	defer logger.Debug("finished")
+
+	if req.GetResource() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing resource")
+	}
+
+	err := h.store.Release(req.GetResource().LocketResource())
+	if err != nil {
+		if err != locket.ErrResourceNotFound {
+			logger.Error("failed-releasing-resource", err)
+		}
+		return nil, models.ToStatusError(err)
+	}
+
+	return &models.ReleaseResponse{}, nil
+}
+
+func (h *locketHandler) Fetch(ctx context.Context, req *models.FetchRequest) (*models.FetchResponse, error) {
+	logger := h.logger.Session("fetch", lager.Data{"request": req})
+	logger.Debug("started")
+	defer logger.Debug("finished")
+
+	resource, err := h.store.Fetch(req.GetKey())
+	if err != nil {
+		if err != locket.ErrResourceNotFound {
+			logger.Error("failed-fetching-resource", err)
+		}
+		return nil, models.ToStatusError(err)
+	}
+
+	return &models.FetchResponse{Resource: models.NewResource(*resource)}, nil
+}
+
+func (h *locketHandler) FetchAll(ctx context.Context, req *models.FetchAllRequest) (*models.FetchAllResponse, error) {
+	logger := h.logger.Session("fetch-all", lager.Data{"request": req})
+	logger.Debug("started")
+	defer logger.Debug("finished")
+
+	if t := req.GetType(); t != "" && t != locket.LockType && t != locket.PresenceType {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid type '%s'", t)
+	}
+
+	resources, err := h.store.FetchAll(req.GetType())
+	if err != nil {
+		logger.Error("failed-fetching-resources", err)
+		return nil, models.ToStatusError(err)
+	}
+
+	response := &models.FetchAllResponse{}
+	for _, resource := range resources {
+		response.Resources = append(response.Resources, models.NewResource(resource))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/fakes"
+	"code.cloudfoundry.org/locket/handlers"
+	"code.cloudfoundry.org/locket/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocketHandler", func() {
+	var (
+		store    *fakes.FakeLockStore
+		handler  models.LocketServer
+		resource *models.Resource
+	)
+
+	BeforeEach(func() {
+		store = &fakes.FakeLockStore{}
+		handler = handlers.NewLocketHandler(lagertest.NewTestLogger("locket"), store)
+		resource = &models.Resource{Key: "some-key", Owner: "some-owner", Value: []byte("value"), Type: locket.LockType}
+	})
+
+	Describe("Lock", func() {
+		It("locks the resource in the store", func() {
+			store.LockReturns(42, nil)
+
+			response, err := handler.Lock(context.Background(), &models.LockRequest{Resource: resource, TtlInSeconds: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Index).To(BeEquivalentTo(42))
+
+			Expect(store.LockCallCount()).To(Equal(1))
+			lockedResource, ttl := store.LockArgsForCall(0)
+			Expect(lockedResource).To(Equal(resource.LocketResource()))
+			Expect(ttl).To(Equal(10 * time.Second))
+		})
+
+		It("reports a collision as AlreadyExists", func() {
+			store.LockReturns(0, locket.ErrLockCollision)
+
+			_, err := handler.Lock(context.Background(), &models.LockRequest{Resource: resource, TtlInSeconds: 10})
+			Expect(status.Code(err)).To(Equal(codes.AlreadyExists))
+		})
+
+		It("rejects a request without a ttl", func() {
+			_, err := handler.Lock(context.Background(), &models.LockRequest{Resource: resource})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(store.LockCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("LockAll", func() {
+		It("locks the resources in the store", func() {
+			store.LockAllReturns([]int64{42, 43}, nil)
+
+			response, err := handler.LockAll(context.Background(), &models.LockAllRequest{Resources: []*models.Resource{resource}, TtlInSeconds: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Indexes).To(Equal([]int64{42, 43}))
+
+			Expect(store.LockAllCallCount()).To(Equal(1))
+			lockedResources, ttl := store.LockAllArgsForCall(0)
+			Expect(lockedResources).To(Equal([]locket.Resource{resource.LocketResource()}))
+			Expect(ttl).To(Equal(10 * time.Second))
+		})
+
+		It("reports a collision as AlreadyExists", func() {
+			store.LockAllReturns(nil, locket.ErrLockCollision)
+
+			_, err := handler.LockAll(context.Background(), &models.LockAllRequest{Resources: []*models.Resource{resource}, TtlInSeconds: 10})
+			Expect(status.Code(err)).To(Equal(codes.AlreadyExists))
+		})
+	})
+
+	Describe("Release", func() {
+		It("reports a missing resource as NotFound", func() {
+			store.ReleaseReturns(locket.ErrResourceNotFound)
+
+			_, err := handler.Release(context.Background(), &models.ReleaseRequest{Resource: resource})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	Describe("Fetch", func() {
+		It("returns the resource", func() {
+			r := resource.LocketResource()
+			store.FetchReturns(&r, nil)
+
+			response, err := handler.Fetch(context.Background(), &models.FetchRequest{Key: "some-key"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Resource.LocketResource()).To(Equal(r))
+		})
+	})
+
+	Describe("FetchAll", func() {
+		It("fetches resources of the requested type", func() {
+			store.FetchAllReturns([]locket.Resource{resource.LocketResource()}, nil)
+
+			response, err := handler.FetchAll(context.Background(), &models.FetchAllRequest{Type: locket.PresenceType})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Resources).To(HaveLen(1))
+			Expect(store.FetchAllArgsForCall(0)).To(Equal(locket.PresenceType))
+		})
+
+		It("rejects an unknown type", func() {
+			_, err := handler.FetchAll(context.Background(), &models.FetchAllRequest{Type: "bogus"})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("reports store failures as Internal", func() {
+			store.FetchAllReturns(nil, errors.New("boom"))
+
+			_, err := handler.FetchAll(context.Background(), &models.FetchAllRequest{})
+			Expect(status.Code(err)).To(Equal(codes.Internal))
+		})
+	})
+})
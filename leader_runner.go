@@ -0,0 +1,81 @@
+package locket
+
+import (
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/tedsuo/ifrit"
+)
+
+// LeaderRunner runs child only while it holds lock. It starts child once the
+// lock is acquired, and signals it and waits for it to exit if the lock is
+// lost. It is ready once child first is.
+type LeaderRunner struct {
+	lock    Lock
+	child   ifrit.Runner
+	standby bool
+
+	logger lager.Logger
+}
+
+// NewLeaderRunner returns a LeaderRunner that exits with ErrLockLost when the
+// lock is lost, or goes back to waiting for the lock when standby is set. It
+// exits with ErrLockRevoked when the lock is force-released, standby or not.
+func NewLeaderRunner(logger lager.Logger, lock Lock, child ifrit.Runner, standby bool) LeaderRunner {
+	return LeaderRunner{
+		lock:    lock,
+		child:   child,
+		standby: standby,
+
+		logger: logger,
+	}
+}
+
+func (r LeaderRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := r.logger.Session("leader-runner", lager.Data{"key": r.lock.key})
+	logger.Info("starting")
+	defer logger.Info("done")
+
+	return r.lock.runHeld(logger, "leader-runner", signals, r.standby, nil, func(handle *LockHandle) (bool, error) {
+		logger.Info("starting-child")
+		process := ifrit.Background(r.child)
+		return r.runChild(logger, process, handle, signals, &ready)
+	})
+}
+
+// FencingToken returns the fencing token of the lock while the child runs,
+// for the child to hand to the systems it writes to.
+func (r LeaderRunner) FencingToken() uint64 {
+	return r.lock.FencingToken()
+}
+
+// runChild waits for process to exit, signalling it first on a signal or
+// when the lock is lost. It reports whether the lock was lost, and why.
+func (r LeaderRunner) runChild(logger lager.Logger, process ifrit.Process, handle *LockHandle, signals <-chan os.Signal, ready *chan<- struct{}) (bool, error) {
+	childReady := process.Ready()
+
+	for {
+		select {
+		case <-childReady:
+			childReady = nil
+			if *ready != nil {
+				close(*ready)
+				*ready = nil
+			}
+			logger.Info("child-started")
+		case sig := <-signals:
+			logger.Info("signalling-child", lager.Data{"received-signal": sig})
+			process.Signal(sig)
+			return false, <-process.Wait()
+		case err := <-process.Wait():
+			logger.Info("child-exited")
+			return false, err
+		case err := <-handle.Lost():
+			logger.Error("lost-lock", err)
+			process.Signal(os.Interrupt)
+			<-process.Wait()
+			logger.Info("child-stopped")
+			return true, err
+		}
+	}
+}
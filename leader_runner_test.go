@@ -0,0 +1,159 @@
+package locket_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/fakes"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaderRunner", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+
+		childStarted chan struct{}
+		childStopped chan os.Signal
+		child        ifrit.Runner
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+
+		childStarted = make(chan struct{}, 10)
+		childStopped = make(chan os.Signal, 10)
+		started, stopped := childStarted, childStopped
+		child = ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			started <- struct{}{}
+			close(ready)
+			sig := <-signals
+			stopped <- sig
+			return nil
+		})
+	})
+
+	holder := func() string {
+		kv, err := backend.GetKey("some-key")
+		Expect(err).NotTo(HaveOccurred())
+		if kv == nil {
+			return ""
+		}
+		return kv.Session
+	}
+
+	newLock := func(value string) locket.Lock {
+		return locket.NewLockWithBackend(logger, backend, "some-key", []byte(value), clock, time.Second, ttl)
+	}
+
+	It("runs the child only while holding the lock", func() {
+		other, err := newLock("other").Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		process := ifrit.Background(locket.NewLeaderRunner(logger, newLock("a"), child, false))
+		Consistently(childStarted).ShouldNot(Receive())
+		Expect(process.Ready()).NotTo(BeClosed())
+
+		other.Release()
+		Eventually(process.Ready()).Should(BeClosed())
+		Expect(childStarted).To(Receive())
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(childStopped).To(Receive(Equal(os.Interrupt)))
+		Expect(holder()).To(BeEmpty())
+	})
+
+	It("stops the child and exits when the lock is lost", func() {
+		process := ifrit.Invoke(locket.NewLeaderRunner(logger, newLock("a"), child, false))
+		Expect(childStarted).To(Receive())
+
+		Expect(backend.DestroySession(holder())).To(Succeed())
+		Eventually(process.Wait()).Should(Receive(Equal(locket.ErrLockLost)))
+		Expect(childStopped).To(Receive())
+	})
+
+	It("goes back to standby when the lock is lost in standby mode", func() {
+		process := ifrit.Invoke(locket.NewLeaderRunner(logger, newLock("a"), child, true))
+		defer func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		}()
+		Expect(childStarted).To(Receive())
+
+		Expect(backend.DestroySession(holder())).To(Succeed())
+		Eventually(childStopped).Should(Receive())
+		Consistently(process.Wait()).ShouldNot(Receive())
+
+		Eventually(childStarted).Should(Receive())
+		Expect(holder()).NotTo(BeEmpty())
+	})
+
+	It("hands the fencing token to the child and reports the lock as held", func() {
+		emitter := &fakes.FakeMetricsEmitter{}
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithMetricsEmitter(emitter))
+
+		var runner locket.LeaderRunner
+		tokens := make(chan uint64, 1)
+		tokenChild := ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			tokens <- runner.FencingToken()
+			close(ready)
+			<-signals
+			return nil
+		})
+		runner = locket.NewLeaderRunner(logger, lock, tokenChild, false)
+
+		process := ifrit.Invoke(runner)
+		defer func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		}()
+
+		var token uint64
+		Expect(tokens).To(Receive(&token))
+		Expect(token).NotTo(BeZero())
+		Expect(lock.FencingToken()).To(Equal(token))
+
+		Expect(emitter.LockHeldCallCount()).To(BeNumerically(">", 0))
+		key, held, _ := emitter.LockHeldArgsForCall(0)
+		Expect(key).To(Equal("some-key"))
+		Expect(held).To(BeTrue())
+
+		recorder := httptest.NewRecorder()
+		locket.NewDebugHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		var info locket.DebugInfo
+		Expect(json.NewDecoder(recorder.Body).Decode(&info)).To(Succeed())
+		Expect(info.Runners).To(ContainElement(And(
+			HaveField("Kind", "leader-runner"),
+			HaveField("Key", "some-key"),
+			HaveField("State", locket.StateHolding),
+		)))
+	})
+
+	It("returns the child's error when it exits on its own", func() {
+		childErr := errors.New("boom")
+		failing := ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			return childErr
+		})
+
+		process := ifrit.Background(locket.NewLeaderRunner(logger, newLock("a"), failing, true))
+		Eventually(process.Wait()).Should(Receive(Equal(childErr)))
+		Expect(holder()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,197 @@
+package locket
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"code.cloudfoundry.org/lager"
+)
+
+// LeaderEvent is the holder of a lock key after it changed. Value and Owner
+// are decoded as in FetchLock. Vacant is set when nobody holds the key.
+type LeaderEvent struct {
+	Key     string
+	Value   []byte
+	Owner   *OwnerInfo
+	Session string
+	Vacant  bool
+}
+
+type LeaderWatcher struct {
+	backend   Backend
+	key       string
+	eventChan chan LeaderEvent
+
+	clock   clock.Clock
+	options runnerOptions
+	logger  lager.Logger
+	status  *statusTracker
+}
+
+func NewLeaderWatcher(
+	logger lager.Logger,
+	consulClient consuladapter.Client,
+	lockKey string,
+	clock clock.Clock,
+	opts ...Option,
+) (LeaderWatcher, <-chan LeaderEvent) {
+	return NewLeaderWatcherWithBackend(logger, NewConsulBackend(consulClient), lockKey, clock, opts...)
+}
+
+// NewLeaderWatcherWithBackend watches a single lock key. It sends the holder
+// it first sees, and then an event every time the holder changes.
+func NewLeaderWatcherWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	clock clock.Clock,
+	opts ...Option,
+) (LeaderWatcher, <-chan LeaderEvent) {
+	eventChan := make(chan LeaderEvent)
+	return LeaderWatcher{
+		backend:   backend,
+		key:       lockKey,
+		eventChan: eventChan,
+
+		clock:   clock,
+		options: newRunnerOptions(opts),
+		logger:  logger,
+		status:  newStatusTracker(clock),
+	}, eventChan
+}
+
+// Status returns what the watcher is doing. It is safe to call from any
+// goroutine.
+func (w LeaderWatcher) Status() Status {
+	return w.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (w LeaderWatcher) StatusChanges() <-chan Status {
+	return w.status.changes
+}
+
+func (w LeaderWatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := w.logger.Session("leader-watcher", lager.Data{"key": w.key})
+	logger.Info("starting")
+	defer logger.Info("done")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer activity.addRunner("leader-watcher", w.key, w.status)()
+	defer w.status.enter(StateStopped, "")
+	events := watchLeader(ctx, logger, w.backend, w.key, w.clock, w.options, w.status)
+
+	defer close(w.eventChan)
+
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			logger.Info("signalled")
+			return nil
+		case event := <-events:
+			select {
+			case w.eventChan <- event:
+			case <-signals:
+				logger.Info("signalled")
+				return nil
+			}
+		}
+	}
+}
+
+func WatchLeader(ctx context.Context, logger lager.Logger, consulClient consuladapter.Client, lockKey string, clock clock.Clock, opts ...Option) <-chan LeaderEvent {
+	return WatchLeaderWithBackend(ctx, logger, NewConsulBackend(consulClient), lockKey, clock, opts...)
+}
+
+// WatchLeaderWithBackend sends the holder of lockKey it first sees, and then
+// an event every time the holder changes, until ctx is done. The channel is
+// closed once an in-flight blocking query returns after that.
+func WatchLeaderWithBackend(ctx context.Context, logger lager.Logger, backend Backend, lockKey string, clock clock.Clock, opts ...Option) <-chan LeaderEvent {
+	return watchLeader(ctx, logger.Session("leader-watcher", lager.Data{"key": lockKey}), backend, lockKey, clock, newRunnerOptions(opts), nil)
+}
+
+const leaderWatchRetryInterval = 1 * time.Second
+
+func watchLeader(ctx context.Context, logger lager.Logger, backend Backend, key string, clock clock.Clock, options runnerOptions, status *statusTracker) <-chan LeaderEvent {
+	events := make(chan LeaderEvent)
+	go func() {
+		defer close(events)
+		watchHolderChanges(logger, backend, key, clock, options.metricsOr(NoopMetricsEmitter{}), status, events, ctx.Done())
+	}()
+
+	return events
+}
+
+func watchHolderChanges(logger lager.Logger, backend Backend, key string, clock clock.Clock, metrics MetricsEmitter, status *statusTracker, events chan<- LeaderEvent, stop <-chan struct{}) {
+	var waitIndex uint64
+	var last *LeaderEvent
+
+	status.enter(StateWatching, "")
+
+	for {
+		kv, index, err := backend.WatchKey(key, waitIndex, defaultWatchBlockDuration)
+		if err != nil {
+			logger.Error("watch-failed", err)
+			metrics.WatchFailed(key, err)
+			status.fail(StateRetrying, err)
+			timer := clock.NewTimer(leaderWatchRetryInterval)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+			waitIndex = 0
+			continue
+		}
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		waitIndex = index
+		status.succeed(StateWatching, "")
+
+		event := newLeaderEvent(key, kv)
+		if last != nil && last.sameHolder(event) {
+			continue
+		}
+
+		logger.Info("holder-changed", lager.Data{"session": event.Session, "vacant": event.Vacant})
+		select {
+		case events <- event:
+		case <-stop:
+			return
+		}
+
+		last = &event
+	}
+}
+
+func newLeaderEvent(key string, kv *KeyValue) LeaderEvent {
+	if kv == nil || kv.Session == "" {
+		return LeaderEvent{Key: key, Vacant: true}
+	}
+
+	owner, value := DecodeOwnerValue(kv.Value)
+	return LeaderEvent{
+		Key:     key,
+		Value:   value,
+		Owner:   owner,
+		Session: kv.Session,
+	}
+}
+
+func (e LeaderEvent) sameHolder(other LeaderEvent) bool {
+	return e.Vacant == other.Vacant && e.Session == other.Session && bytes.Equal(e.Value, other.Value)
+}
@@ -0,0 +1,100 @@
+package locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaderWatcher", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock          *fakeclock.FakeClock
+		backend        *locket.MemoryBackend
+		lockKey        string
+		logger         *lagertest.TestLogger
+		watcherProcess ifrit.Process
+		events         <-chan locket.LeaderEvent
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		lockKey = locket.LockSchemaPath("some-key")
+		logger = lagertest.NewTestLogger("locket")
+
+		var watcherRunner ifrit.Runner
+		watcherRunner, events = locket.NewLeaderWatcherWithBackend(logger, backend, lockKey, clock)
+		watcherProcess = ifrit.Invoke(watcherRunner)
+	})
+
+	AfterEach(func() {
+		ginkgomon.Kill(watcherProcess)
+	})
+
+	acquire := func(name string, value []byte) string {
+		sessionID, err := backend.CreateSession(name, ttl, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = backend.AcquireKey(sessionID, lockKey, value, locket.LockType, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return sessionID
+	}
+
+	It("streams holder changes", func() {
+		Eventually(events).Should(Receive(Equal(locket.LeaderEvent{Key: lockKey, Vacant: true})))
+
+		sessionA := acquire("session-a", []byte("a"))
+		Eventually(events).Should(Receive(Equal(locket.LeaderEvent{Key: lockKey, Value: []byte("a"), Session: sessionA})))
+
+		Expect(backend.DestroySession(sessionA)).To(Succeed())
+		Eventually(events).Should(Receive(Equal(locket.LeaderEvent{Key: lockKey, Vacant: true})))
+
+		sessionB := acquire("session-b", []byte("b"))
+		Eventually(events).Should(Receive(Equal(locket.LeaderEvent{Key: lockKey, Value: []byte("b"), Session: sessionB})))
+	})
+
+	It("does not send an event when the holder is unchanged", func() {
+		Eventually(events).Should(Receive())
+
+		acquire("some-session", []byte("a"))
+		Eventually(events).Should(Receive())
+
+		sessionID, err := backend.CreateSession("other-session", ttl, true)
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = backend.AcquireKey(sessionID, locket.LockSchemaPath("other-key"), nil, locket.LockType, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Consistently(events).ShouldNot(Receive())
+	})
+
+	It("decodes owner values", func() {
+		Eventually(events).Should(Receive())
+
+		owner := locket.NewOwnerInfo("some-owner", "", nil)
+		value, err := locket.EncodeOwnerValue(owner, []byte("some-value"))
+		Expect(err).NotTo(HaveOccurred())
+		acquire("some-session", value)
+
+		var event locket.LeaderEvent
+		Eventually(events).Should(Receive(&event))
+		Expect(event.Value).To(Equal([]byte("some-value")))
+		Expect(event.Owner.OwnerID).To(Equal("some-owner"))
+	})
+
+	It("closes the event channel when signalled", func() {
+		Eventually(events).Should(Receive())
+
+		ginkgomon.Interrupt(watcherProcess)
+		acquire("some-session", []byte("a"))
+		Eventually(events).Should(BeClosed())
+	})
+})
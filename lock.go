@@ -1,35 +1,45 @@
 package locket
 
 import (
+	"context"
 	"errors"
 	"os"
-	"strings"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/consuladapter"
 	"code.cloudfoundry.org/lager"
-	"code.cloudfoundry.org/runtimeschema/metric"
 	"github.com/nu7hatch/gouuid"
 )
 
 var (
-	ErrLockLost = errors.New("lock lost")
+	ErrLockLost    = errors.New("lock lost")
+	ErrLockRevoked = errors.New("lock revoked")
 )
 
 type Lock struct {
-	consul *Session
-	key    string
-	value  []byte
+	backend Backend
+	consul  *Session
+	key     string
+	value   []byte
 
 	clock         clock.Clock
 	retryInterval time.Duration
+	options       runnerOptions
 
 	logger lager.Logger
 
-	lockAcquiredMetric metric.Metric
-	lockUptimeMetric   metric.Duration
-	lockAcquiredTime   time.Time
+	metrics MetricsEmitter
+
+	state  *lockState
+	status *statusTracker
+}
+
+type lockState struct {
+	lock         sync.Mutex
+	fencingToken uint64
+	acquiredAt   time.Time
 }
 
 func NewLock(
@@ -40,129 +50,514 @@ func NewLock(
 	clock clock.Clock,
 	retryInterval time.Duration,
 	lockTTL time.Duration,
+	opts ...Option,
 ) Lock {
-	lockMetricName := strings.Replace(lockKey, "/", "-", -1)
+	return NewLockWithBackend(logger, NewConsulBackend(consulClient), lockKey, lockValue, clock, retryInterval, lockTTL, opts...)
+}
 
+func NewLockWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) Lock {
 	uuid, err := uuid.NewV4()
 	if err != nil {
 		logger.Fatal("create-uuid-failed", err)
 	}
 
-	session, err := NewSessionNoChecks(uuid.String(), lockTTL, consulClient)
+	session, err := NewSessionNoChecksWithBackend(uuid.String(), lockTTL, backend)
 	if err != nil {
 		logger.Fatal("consul-session-failed", err)
 	}
 
+	options := newRunnerOptions(opts)
+
 	return Lock{
-		consul: session,
-		key:    lockKey,
-		value:  lockValue,
+		backend: backend,
+		consul:  session,
+		key:     lockKey,
+		value:   lockValue,
 
 		clock:         clock,
 		retryInterval: retryInterval,
+		options:       options,
 
 		logger: logger,
 
-		lockAcquiredMetric: metric.Metric("LockHeld." + lockMetricName),
-		lockUptimeMetric:   metric.Duration("LockHeldDuration." + lockMetricName),
+		metrics: options.metricsOr(NewDropsondeMetricsEmitter(logger)),
+
+		state:  &lockState{},
+		status: newStatusTracker(clock),
 	}
 }
 
-func (l Lock) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
-	logger := l.logger.Session("lock", lager.Data{"key": l.key, "value": string(l.value)})
-	logger.Info("starting")
+// FencingToken returns the token of the current acquisition of the lock, or 0
+// when it is not held. Downstream systems can reject writes carrying a token
+// lower than one they have already seen.
+func (l Lock) FencingToken() uint64 {
+	l.state.lock.Lock()
+	defer l.state.lock.Unlock()
+	return l.state.fencingToken
+}
+
+// Status returns what the lock is doing. It is safe to call from any
+// goroutine.
+func (l Lock) Status() Status {
+	return l.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (l Lock) StatusChanges() <-chan Status {
+	return l.status.changes
+}
 
-	defer func() {
-		l.consul.Destroy()
-		logger.Info("done")
+// setFencingToken records the token of the current acquisition, and when it
+// was acquired, or 0 once the lock is no longer held.
+func (l Lock) setFencingToken(token uint64) {
+	l.state.lock.Lock()
+	l.state.fencingToken = token
+	if token != 0 {
+		l.state.acquiredAt = l.clock.Now()
+	}
+	l.state.lock.Unlock()
+}
+
+// LockHandle is a held Lock. Lost receives an error if the lock is lost
+// before it is released.
+type LockHandle struct {
+	key          string
+	session      *Session
+	fencingToken uint64
+	hooks        LockHooks
+	status       *statusTracker
+	clock        clock.Clock
+	logger       lager.Logger
+
+	lostCh      chan error
+	releaseOnce sync.Once
+
+	lock     sync.Mutex
+	lost     bool
+	released chan struct{}
+}
+
+func (l Lock) newLockHandle(logger lager.Logger, session *Session, fencingToken uint64) *LockHandle {
+	h := &LockHandle{
+		key:          l.key,
+		session:      session,
+		fencingToken: fencingToken,
+		hooks:        l.options.lockHooks,
+		status:       l.status,
+		clock:        l.clock,
+		logger:       logger,
+		lostCh:       make(chan error, 1),
+		released:     make(chan struct{}),
+	}
+
+	h.hooks.acquired(h.key, fencingToken)
+
+	go func() {
+		select {
+		case err := <-session.Err():
+			h.lock.Lock()
+			select {
+			case <-h.released:
+				h.lock.Unlock()
+				return
+			default:
+			}
+			h.lost = true
+			h.lock.Unlock()
+
+			if err == nil {
+				err = ErrLockLost
+			}
+			if l.revoked(logger, session.ID()) {
+				err = ErrLockRevoked
+			}
+			h.status.fail(StateLost, err)
+			h.hooks.lost(h.key, err)
+			h.lostCh <- err
+		case <-h.released:
+		}
 	}()
 
-	acquireErr := make(chan error, 1)
+	return h
+}
+
+// revoked reports whether the lock was lost to ForceRelease.
+func (l Lock) revoked(logger lager.Logger, sessionID string) bool {
+	revocation, err := FetchRevocationWithBackend(l.backend, l.key, sessionID)
+	if err != nil {
+		logger.Error("failed-fetching-revocation", err)
+		return false
+	}
+	if revocation == nil {
+		return false
+	}
+
+	logger.Info("lock-revoked", lager.Data{
+		"operator":   revocation.Operator,
+		"reason":     revocation.Reason,
+		"revoked-at": revocation.RevokedAt,
+	})
+	return true
+}
+
+func (h *LockHandle) Lost() <-chan error {
+	return h.lostCh
+}
+
+func (h *LockHandle) FencingToken() uint64 {
+	return h.fencingToken
+}
+
+// Release gives up the lock, after the OnReleasing hook returns or times
+// out. It is safe to call more than once.
+func (h *LockHandle) Release() {
+	h.releaseOnce.Do(func() {
+		h.lock.Lock()
+		lost := h.lost
+		h.lock.Unlock()
+
+		if !lost && !h.hooks.releasing(h.clock, h.key) {
+			h.logger.Info("release-hook-timed-out")
+		}
+
+		h.lock.Lock()
+		close(h.released)
+		lost = h.lost
+		h.lock.Unlock()
+
+		h.session.Destroy()
+
+		if !lost {
+			h.status.enter(StateStopped, "")
+			h.hooks.released(h.key)
+		}
+	})
+}
+
+// Acquire blocks until the lock is held or ctx is done, backing off between
+// failed attempts until the retry policy gives up. A failed attempt on a key
+// held by someone else is retried as soon as the key is released, without
+// waiting out the backoff.
+func (l Lock) Acquire(ctx context.Context) (*LockHandle, error) {
+	logger := l.logger.Session("lock", lager.Data{"key": l.key, "value": string(l.value)})
+	return l.acquire(ctx, logger)
+}
+
+func (l Lock) acquire(ctx context.Context, logger lager.Logger) (*LockHandle, error) {
+	type acquireResult struct {
+		token uint64
+		err   error
+	}
+	acquired := make(chan acquireResult, 1)
 
 	acquire := func(session *Session) {
 		logger.Info("acquiring-lock")
-		acquireErr <- session.AcquireLock(l.key, l.value)
+		token, err := session.AcquireLock(l.key, l.value)
+		if err == nil {
+			recordAcquiredAt(logger, session, l.key, l.value, l.clock.Now())
+		}
+		acquired <- acquireResult{token: token, err: err}
 	}
 
 	var c <-chan time.Time
-	var reemit <-chan time.Time
+	var vacated <-chan struct{}
+	var failures int
+	retry := newRetrier(l.options.backoffOr(l.retryInterval))
 
-	go acquire(l.consul)
+	stopWatching := make(chan struct{})
+	defer func() { close(stopWatching) }()
+
+	start := l.clock.Now()
+	session := l.consul.clone()
+	l.status.enter(StateAcquiring, "")
+	go acquire(session)
 
 	for {
 		select {
-		case sig := <-signals:
-			logger.Info("shutting-down", lager.Data{"received-signal": sig})
-
-			logger.Debug("releasing-lock")
-			l.consul.Destroy()
-			l.emitMetrics(false)
-			return nil
-		case err := <-l.consul.Err():
-			if ready == nil {
-				logger.Error("lost-lock", err)
-				l.emitMetrics(false)
-				return ErrLockLost
-			}
-
+		case <-ctx.Done():
+			session.Destroy()
+			return nil, ctx.Err()
+		case <-vacated:
+			logger.Info("lock-vacated")
+			vacated = nil
+			c = l.clock.NewTimer(0).C()
+		case err := <-session.Err():
 			logger.Error("consul-error-without-lock", err)
-		case err := <-acquireErr:
-			if err != nil {
-				logger.Error("acquire-lock-failed", err)
+		case result := <-acquired:
+			l.metrics.LockAttempted(l.key, result.err)
+			if result.err != nil {
+				logger.Error("acquire-lock-failed", result.err, lager.Data{"retryable": IsRetryable(result.err)})
 				l.emitMetrics(false)
-				c = l.clock.NewTimer(l.retryInterval).C()
+				l.status.fail(StateRetrying, result.err)
+
+				failures++
+				if err := l.options.retryPolicy.check(result.err, failures); err != nil {
+					logger.Error("giving-up-acquiring-lock", err, lager.Data{"failures": failures})
+					session.Destroy()
+					return nil, err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
+				vacated = waitForVacancy(l.backend, l.key, stopWatching)
 				break
 			}
 
-			logger.Info("acquire-lock-succeeded")
-			l.lockAcquiredTime = l.clock.Now()
-			l.emitMetrics(true)
-			reemit = l.clock.NewTimer(30 * time.Second).C()
-			close(ready)
-			ready = nil
-			c = nil
-			logger.Info("started")
-		case <-reemit:
-			l.emitMetrics(true)
-			reemit = l.clock.NewTimer(30 * time.Second).C()
+			logger.Info("acquire-lock-succeeded", lager.Data{"fencing-token": result.token})
+			l.metrics.LockAcquired(l.key, l.clock.Since(start))
+			l.status.succeed(StateHolding, session.ID())
+			return l.newLockHandle(logger, session, result.token), nil
 		case <-c:
 			logger.Info("retrying-acquiring-lock")
-			newSession, err := l.consul.Recreate()
+			l.status.enter(StateRecreatingSession, session.ID())
+			newSession, err := session.Recreate()
 			if err != nil {
-				logger.Error("failed-to-recreate-session", err)
-				c = l.clock.NewTimer(l.retryInterval).C()
+				logger.Error("failed-to-recreate-session", err, lager.Data{"retryable": IsRetryable(err)})
+				l.status.fail(StateRetrying, err)
+
+				failures++
+				if err := l.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-lock", err, lager.Data{"failures": failures})
+					session.Destroy()
+					return nil, err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
 			} else {
-				l.consul = newSession
+				session = newSession
+				l.status.enter(StateAcquiring, session.ID())
 				c = nil
+				vacated = nil
+				close(stopWatching)
+				stopWatching = make(chan struct{})
 				go acquire(newSession)
 			}
 		}
 	}
 }
 
-func (l Lock) emitMetrics(acquired bool) {
-	var acqVal int
-	var uptime time.Duration
+// waitForVacancy closes the returned channel once key, held by another
+// session, is released. It never does when the key is already vacant or
+// cannot be watched, leaving those failures to the backoff.
+func waitForVacancy(backend Backend, key string, stop <-chan struct{}) <-chan struct{} {
+	vacated := make(chan struct{})
 
-	if acquired {
-		acqVal = 1
-		uptime = l.clock.Since(l.lockAcquiredTime)
-	} else {
-		acqVal = 0
-		uptime = 0
+	go func() {
+		var waitIndex uint64
+		for {
+			kv, index, err := backend.WatchKey(key, waitIndex, defaultWatchBlockDuration)
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			if kv == nil || kv.Session == "" {
+				if waitIndex != 0 {
+					close(vacated)
+				}
+				return
+			}
+
+			waitIndex = index
+		}
+	}()
+
+	return vacated
+}
+
+// LeadershipChange is sent by a Lock in standby mode every time it acquires
+// or loses the lock. Err is why it was lost.
+type LeadershipChange struct {
+	Held         bool
+	FencingToken uint64
+	Err          error
+}
+
+// WithStandbyAfterLoss makes Lock.Run go back to acquiring the lock when it
+// is lost, instead of returning ErrLockLost, and send every acquisition and
+// loss on changes. Run blocks until each change is received. A lock taken
+// away with ForceRelease still ends Run with ErrLockRevoked.
+func WithStandbyAfterLoss(changes chan<- LeadershipChange) Option {
+	return func(o *runnerOptions) {
+		o.standby = true
+		o.leadership = changes
 	}
+}
+
+func (l Lock) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := l.logger.Session("lock", lager.Data{"key": l.key, "value": string(l.value)})
+	logger.Info("starting")
+	defer logger.Info("done")
 
-	l.logger.Debug("reemit-lock-uptime", lager.Data{"uptime": uptime,
-		"uptimeMetricName":       l.lockUptimeMetric,
-		"lockAcquiredMetricName": l.lockAcquiredMetric,
+	acquired := func() {
+		if ready != nil {
+			close(ready)
+			ready = nil
+		}
+		logger.Info("started")
+	}
+
+	return l.runHeld(logger, "lock", signals, l.options.standby, acquired, func(handle *LockHandle) (bool, error) {
+		select {
+		case sig := <-signals:
+			logger.Info("shutting-down", lager.Data{"received-signal": sig})
+			logger.Debug("releasing-lock")
+			return false, nil
+		case err := <-handle.Lost():
+			logger.Error("lost-lock", err)
+			return true, err
+		}
 	})
-	err := l.lockUptimeMetric.Send(uptime)
-	if err != nil {
-		l.logger.Error("failed-to-send-lock-uptime-metric", err)
+}
+
+// runHeld acquires the lock, calls acquired, if not nil, and then held while
+// holding it, and goes back to acquiring it when it is lost in standby. It
+// keeps the fencing token, the LockHeld metric and the leadership changes up
+// to date, and registers the runner as kind with the debug handler. held
+// reports whether the lock was lost, and why; when it was not, runHeld
+// returns its error.
+func (l Lock) runHeld(logger lager.Logger, kind string, signals <-chan os.Signal, standby bool, acquired func(), held func(handle *LockHandle) (bool, error)) error {
+	defer activity.addRunner(kind, l.key, l.status)()
+	defer l.status.enter(StateStopped, "")
+
+	for {
+		handle, err := l.acquireUntilSignalled(logger, signals)
+		if err != nil {
+			return err
+		}
+		if handle == nil {
+			return nil
+		}
+
+		l.setFencingToken(handle.FencingToken())
+		l.emitMetrics(true)
+		stopReemitting := l.reemitMetrics()
+		if acquired != nil {
+			acquired()
+		}
+
+		var lost bool
+		if l.notifyLeadership(LeadershipChange{Held: true, FencingToken: handle.FencingToken()}, signals) {
+			lost, err = held(handle)
+		}
+		stopReemitting()
+
+		handle.Release()
+		l.setFencingToken(0)
+		l.emitMetrics(false)
+
+		if !lost {
+			return err
+		}
+
+		if errors.Is(err, ErrLockRevoked) {
+			return ErrLockRevoked
+		}
+		if !standby {
+			return ErrLockLost
+		}
+
+		logger.Info("standing-by")
+		if !l.notifyLeadership(LeadershipChange{Err: err}, signals) {
+			return nil
+		}
 	}
+}
 
-	err = l.lockAcquiredMetric.Send(acqVal)
-	if err != nil {
-		l.logger.Error("failed-to-send-lock-acquired-metric", err)
+// acquireUntilSignalled returns a nil handle when signalled before the lock
+// is acquired.
+func (l Lock) acquireUntilSignalled(logger lager.Logger, signals <-chan os.Signal) (*LockHandle, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type acquireResult struct {
+		handle *LockHandle
+		err    error
+	}
+	acquired := make(chan acquireResult, 1)
+	go func() {
+		handle, err := l.acquire(ctx, logger)
+		acquired <- acquireResult{handle, err}
+	}()
+
+	select {
+	case sig := <-signals:
+		logger.Info("shutting-down", lager.Data{"received-signal": sig})
+		cancel()
+		if result := <-acquired; result.handle != nil {
+			result.handle.Release()
+		}
+		l.emitMetrics(false)
+		return nil, nil
+	case result := <-acquired:
+		return result.handle, result.err
+	}
+}
+
+// reemitMetrics emits the LockHeld metric every 30 seconds until the
+// returned function is called.
+func (l Lock) reemitMetrics() func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	ticker := l.clock.NewTicker(30 * time.Second)
+
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				l.emitMetrics(true)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// notifyLeadership sends change to the leadership channel, if any. It
+// returns false when signalled first.
+func (l Lock) notifyLeadership(change LeadershipChange, signals <-chan os.Signal) bool {
+	if l.options.leadership == nil {
+		return true
+	}
+
+	select {
+	case l.options.leadership <- change:
+		return true
+	case <-signals:
+		return false
+	}
+}
+
+func (l Lock) emitMetrics(acquired bool) {
+	var uptime time.Duration
+	if acquired {
+		l.state.lock.Lock()
+		uptime = l.clock.Since(l.state.acquiredAt)
+		l.state.lock.Unlock()
 	}
+
+	l.metrics.LockHeld(l.key, acquired, uptime)
 }
@@ -0,0 +1,83 @@
+package locket
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+const DefaultReleaseTimeout = 5 * time.Second
+
+// LockHooks are called as a Lock moves through its life. Each is optional,
+// and they are called from the goroutine driving the lock, so they should
+// return promptly.
+type LockHooks struct {
+	// OnAcquired is called once the lock is held, with its fencing token.
+	OnAcquired func(key string, fencingToken uint64)
+
+	// OnReleasing is called before a held lock is given up. The release
+	// waits for it to return, for up to ReleaseTimeout.
+	OnReleasing func(key string)
+
+	// OnReleased is called once the lock has been given up.
+	OnReleased func(key string)
+
+	// OnLost is called when a held lock is lost without being released.
+	OnLost func(key string, err error)
+
+	// ReleaseTimeout bounds how long OnReleasing can delay the release.
+	// DefaultReleaseTimeout is used when it is zero.
+	ReleaseTimeout time.Duration
+}
+
+func WithLockHooks(hooks LockHooks) Option {
+	return func(o *runnerOptions) {
+		o.lockHooks = hooks
+	}
+}
+
+func (h LockHooks) acquired(key string, fencingToken uint64) {
+	if h.OnAcquired != nil {
+		h.OnAcquired(key, fencingToken)
+	}
+}
+
+// releasing returns false when OnReleasing ran past the release timeout.
+func (h LockHooks) releasing(clock clock.Clock, key string) bool {
+	if h.OnReleasing == nil {
+		return true
+	}
+
+	timeout := h.ReleaseTimeout
+	if timeout == 0 {
+		timeout = DefaultReleaseTimeout
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.OnReleasing(key)
+	}()
+
+	timer := clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C():
+		return false
+	}
+}
+
+func (h LockHooks) released(key string) {
+	if h.OnReleased != nil {
+		h.OnReleased(key)
+	}
+}
+
+func (h LockHooks) lost(key string, err error) {
+	if h.OnLost != nil {
+		h.OnLost(key, err)
+	}
+}
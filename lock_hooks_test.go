@@ -0,0 +1,123 @@
+package locket_test
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LockHooks", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+
+		acquired  chan uint64
+		releasing chan struct{}
+		unblock   chan struct{}
+		released  chan struct{}
+		lost      chan error
+		hooks     locket.LockHooks
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+
+		acquired = make(chan uint64, 1)
+		releasing = make(chan struct{}, 1)
+		unblock = make(chan struct{})
+		released = make(chan struct{}, 1)
+		lost = make(chan error, 1)
+
+		// the hooks outlive a spec when they time out
+		acquired, releasing, unblock, released, lost := acquired, releasing, unblock, released, lost
+		hooks = locket.LockHooks{
+			OnAcquired: func(key string, fencingToken uint64) { acquired <- fencingToken },
+			OnReleasing: func(key string) {
+				releasing <- struct{}{}
+				<-unblock
+			},
+			OnReleased: func(key string) { released <- struct{}{} },
+			OnLost:     func(key string, err error) { lost <- err },
+		}
+	})
+
+	holder := func() string {
+		kv, err := backend.GetKey("some-key")
+		Expect(err).NotTo(HaveOccurred())
+		if kv == nil {
+			return ""
+		}
+		return kv.Session
+	}
+
+	It("reports the fencing token on acquisition", func() {
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithLockHooks(hooks))
+		process := ifrit.Invoke(lock)
+		defer func() {
+			close(unblock)
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		}()
+
+		Eventually(acquired).Should(Receive(Equal(lock.FencingToken())))
+	})
+
+	It("holds on to the lock until OnReleasing returns", func() {
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithLockHooks(hooks))
+		process := ifrit.Invoke(lock)
+
+		process.Signal(os.Interrupt)
+		Eventually(releasing).Should(Receive())
+		Consistently(process.Wait()).ShouldNot(Receive())
+		Expect(holder()).NotTo(BeEmpty())
+
+		close(unblock)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(holder()).To(BeEmpty())
+		Expect(released).To(Receive())
+	})
+
+	It("releases the lock once OnReleasing times out", func() {
+		defer close(unblock)
+
+		hooks.ReleaseTimeout = 2 * time.Second
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithLockHooks(hooks))
+		process := ifrit.Invoke(lock)
+
+		process.Signal(os.Interrupt)
+		Eventually(releasing).Should(Receive())
+		Consistently(process.Wait()).ShouldNot(Receive())
+
+		clock.WaitForWatcherAndIncrement(2 * time.Second)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(holder()).To(BeEmpty())
+	})
+
+	It("reports a lost lock without releasing it", func() {
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithLockHooks(hooks))
+		process := ifrit.Invoke(lock)
+
+		Expect(backend.DestroySession(holder())).To(Succeed())
+		Eventually(lost).Should(Receive(HaveOccurred()))
+		Eventually(process.Wait()).Should(Receive(Equal(locket.ErrLockLost)))
+
+		Expect(releasing).NotTo(Receive())
+		Expect(released).NotTo(Receive())
+	})
+})
@@ -0,0 +1,94 @@
+package locket_test
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithStandbyAfterLoss", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+		changes chan locket.LeadershipChange
+		lock    locket.Lock
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		changes = make(chan locket.LeadershipChange)
+
+		lock = locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithStandbyAfterLoss(changes))
+		process = ifrit.Background(lock)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	holder := func() string {
+		kv, err := backend.GetKey("some-key")
+		Expect(err).NotTo(HaveOccurred())
+		if kv == nil {
+			return ""
+		}
+		return kv.Session
+	}
+
+	It("goes back to acquiring the lock after losing it", func() {
+		var change locket.LeadershipChange
+		Eventually(changes).Should(Receive(&change))
+		Expect(change.Held).To(BeTrue())
+		Eventually(process.Ready()).Should(BeClosed())
+		firstToken := change.FencingToken
+
+		Expect(backend.DestroySession(holder())).To(Succeed())
+		Eventually(changes).Should(Receive(&change))
+		Expect(change.Held).To(BeFalse())
+		Expect(change.Err).To(HaveOccurred())
+		Consistently(process.Wait()).ShouldNot(Receive())
+
+		Eventually(changes).Should(Receive(&change))
+		Expect(change.Held).To(BeTrue())
+		Expect(change.FencingToken).To(BeNumerically(">", firstToken))
+		Expect(holder()).NotTo(BeEmpty())
+	})
+
+	It("reports the lock as lost, not stopped, until it acquires it again", func() {
+		var change locket.LeadershipChange
+		Eventually(changes).Should(Receive(&change))
+
+		Expect(backend.DestroySession(holder())).To(Succeed())
+		Eventually(lock.Status).Should(HaveField("State", locket.StateLost))
+		Expect(lock.Status().LastError).To(HaveOccurred())
+
+		Eventually(changes).Should(Receive(&change))
+		Expect(change.Held).To(BeFalse())
+		Eventually(changes).Should(Receive(&change))
+		Expect(change.Held).To(BeTrue())
+		Expect(lock.Status().State).To(Equal(locket.StateHolding))
+	})
+
+	It("can be signalled while a change is waiting to be received", func() {
+		Eventually(process.Ready()).Should(BeClosed())
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(holder()).To(BeEmpty())
+	})
+})
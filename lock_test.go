@@ -41,16 +41,12 @@ var _ = Describe("Lock", func() {
 	)
 
 	getLockValue := func() ([]byte, error) {
-		kvPair, _, err := consulClient.KV().Get(lockKey, nil)
+		holder, err := locket.FetchLock(consulClient, lockKey)
 		if err != nil {
 			return nil, err
 		}
 
-		if kvPair == nil || kvPair.Session == "" {
-			return nil, consuladapter.NewKeyNotFoundError(lockKey)
-		}
-
-		return kvPair.Value, nil
+		return holder.Value, nil
 	}
 
 	BeforeEach(func() {
@@ -159,7 +155,7 @@ var _ = Describe("Lock", func() {
 						ginkgomon.Interrupt(lockProcess)
 						Eventually(lockProcess.Wait()).Should(Receive(BeNil()))
 						_, err := getLockValue()
-						Expect(err).To(Equal(consuladapter.NewKeyNotFoundError(lockKey)))
+						Expect(err).To(Equal(locket.LockNotFoundError(lockKey)))
 					})
 				})
 			})
@@ -0,0 +1,418 @@
+package locket
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/nu7hatch/gouuid"
+)
+
+// MemoryBackend is an in-process Backend with Consul-like semantics: sessions
+// expire when they are not renewed within their TTL, and keys held by a
+// session are deleted when it is invalidated. All timing is driven by the
+// given clock, so it can be used with a fakeclock in tests.
+type MemoryBackend struct {
+	clock clock.Clock
+
+	lock     sync.Mutex
+	index    uint64
+	sessions map[string]*memorySession
+	keys     map[string]*memoryKey
+	changed  chan struct{}
+}
+
+type memorySession struct {
+	name      string
+	ttl       time.Duration
+	expiresAt time.Time
+	doneCh    chan struct{}
+}
+
+type memoryKey struct {
+	value       []byte
+	lockType    string
+	session     string
+	modifyIndex uint64
+	acquiredAt  time.Time
+	lostCh      chan struct{}
+}
+
+func NewMemoryBackend(clock clock.Clock) *MemoryBackend {
+	return &MemoryBackend{
+		clock:    clock,
+		index:    1,
+		sessions: map[string]*memorySession{},
+		keys:     map[string]*memoryKey{},
+		changed:  make(chan struct{}),
+	}
+}
+
+func (b *MemoryBackend) CreateSession(name string, ttl time.Duration, noChecks bool) (string, error) {
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	id := uuid.String()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for existingID, s := range b.sessions {
+		if s.name == name {
+			b.invalidate(existingID)
+		}
+	}
+
+	s := &memorySession{
+		name:      name,
+		ttl:       ttl,
+		expiresAt: b.clock.Now().Add(ttl),
+		doneCh:    make(chan struct{}),
+	}
+	b.sessions[id] = s
+
+	go b.expire(id, s)
+
+	return id, nil
+}
+
+func (b *MemoryBackend) RenewSession(id string, ttl time.Duration, doneCh chan struct{}, renewed func(time.Time)) error {
+	b.lock.Lock()
+	s, ok := b.sessions[id]
+	b.lock.Unlock()
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	if renewed != nil {
+		renewed(b.clock.Now())
+	}
+
+	timer := b.clock.NewTimer(ttl / 2)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-doneCh:
+			b.DestroySession(id)
+			return nil
+		case <-s.doneCh:
+			return ErrInvalidSession
+		case <-timer.C():
+			b.lock.Lock()
+			if _, ok := b.sessions[id]; !ok {
+				b.lock.Unlock()
+				return ErrInvalidSession
+			}
+			s.expiresAt = b.clock.Now().Add(s.ttl)
+			b.lock.Unlock()
+
+			if renewed != nil {
+				renewed(b.clock.Now())
+			}
+
+			timer.Reset(ttl / 2)
+		}
+	}
+}
+
+func (b *MemoryBackend) DestroySession(id string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.invalidate(id)
+	return nil
+}
+
+func (b *MemoryBackend) SessionInfo(id string) (*SessionInfo, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s, ok := b.sessions[id]
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	return &SessionInfo{ID: id, Name: s.name}, nil
+}
+
+func (b *MemoryBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	for {
+		b.lock.Lock()
+		lostCh, index, err := b.tryAcquire(sessionID, key, value, lockType)
+		if err != nil || lostCh != nil {
+			b.lock.Unlock()
+			return lostCh, index, err
+		}
+
+		changed := b.changed
+		b.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-stopCh:
+			return nil, 0, nil
+		}
+	}
+}
+
+func (b *MemoryBackend) TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.tryAcquire(sessionID, key, value, lockType)
+}
+
+func (b *MemoryBackend) AcquireKeys(sessionID string, kvs []KeyValue) ([]<-chan struct{}, string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.sessions[sessionID]; !ok {
+		return nil, "", ErrInvalidSession
+	}
+
+	for _, kv := range kvs {
+		if k, ok := b.keys[kv.Key]; ok && k.session != "" && k.session != sessionID {
+			return nil, kv.Key, nil
+		}
+	}
+
+	lostChs := make([]<-chan struct{}, 0, len(kvs))
+	for _, kv := range kvs {
+		lostCh, _, err := b.tryAcquire(sessionID, kv.Key, kv.Value, kv.Type)
+		if err != nil {
+			return nil, "", err
+		}
+		lostChs = append(lostChs, lostCh)
+	}
+
+	return lostChs, "", nil
+}
+
+func (b *MemoryBackend) ReleaseKey(sessionID, key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.sessions[sessionID]; !ok {
+		return ErrInvalidSession
+	}
+
+	k, ok := b.keys[key]
+	if !ok || k.session != sessionID {
+		return ErrInvalidSession
+	}
+
+	delete(b.keys, key)
+	close(k.lostCh)
+
+	b.index++
+	b.notify()
+	return nil
+}
+
+func (b *MemoryBackend) UpdateKey(sessionID, key string, value []byte) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	k, ok := b.keys[key]
+	if !ok || k.session != sessionID {
+		return ErrInvalidSession
+	}
+
+	b.index++
+	k.value = value
+	k.modifyIndex = b.index
+	b.notify()
+	return nil
+}
+
+func (b *MemoryBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]KeyValue, uint64, error) {
+	b.waitForIndex(waitIndex, waitTime)
+	defer b.lock.Unlock()
+
+	return b.list(prefix), b.index, nil
+}
+
+func (b *MemoryBackend) PutKey(key string, value []byte) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	k, ok := b.keys[key]
+	if !ok {
+		k = &memoryKey{lostCh: make(chan struct{})}
+		b.keys[key] = k
+	}
+	b.index++
+	k.value = value
+	k.modifyIndex = b.index
+	b.notify()
+
+	return nil
+}
+
+func (b *MemoryBackend) DeleteKey(key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	k, ok := b.keys[key]
+	if !ok {
+		return nil
+	}
+	if k.session != "" {
+		return ErrKeyConflict
+	}
+
+	delete(b.keys, key)
+	close(k.lostCh)
+
+	b.index++
+	b.notify()
+	return nil
+}
+
+func (b *MemoryBackend) GetKey(key string) (*KeyValue, error) {
+	kv, _, err := b.WatchKey(key, 0, 0)
+	return kv, err
+}
+
+// WatchKey returns on any change to the backend, not only to the key, the
+// same way a Consul blocking query may return without the key changing.
+func (b *MemoryBackend) WatchKey(key string, waitIndex uint64, waitTime time.Duration) (*KeyValue, uint64, error) {
+	b.waitForIndex(waitIndex, waitTime)
+	defer b.lock.Unlock()
+
+	k, ok := b.keys[key]
+	if !ok {
+		return nil, b.index, nil
+	}
+
+	kv := k.keyValue(key)
+	return &kv, b.index, nil
+}
+
+// waitForIndex blocks until the index passes waitIndex or waitTime elapses,
+// and returns with the lock held.
+func (b *MemoryBackend) waitForIndex(waitIndex uint64, waitTime time.Duration) {
+	var timeout <-chan time.Time
+
+	for {
+		b.lock.Lock()
+		if waitIndex == 0 || b.index > waitIndex || timeout == nil && waitTime <= 0 {
+			return
+		}
+		changed := b.changed
+		b.lock.Unlock()
+
+		if timeout == nil {
+			timer := b.clock.NewTimer(waitTime)
+			defer timer.Stop()
+			timeout = timer.C()
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			b.lock.Lock()
+			return
+		}
+	}
+}
+
+func (b *MemoryBackend) expire(id string, s *memorySession) {
+	timer := b.clock.NewTimer(s.ttl)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.doneCh:
+			return
+		case <-timer.C():
+			b.lock.Lock()
+			now := b.clock.Now()
+			if !now.Before(s.expiresAt) {
+				b.invalidate(id)
+				b.lock.Unlock()
+				return
+			}
+			remaining := s.expiresAt.Sub(now)
+			b.lock.Unlock()
+
+			timer.Reset(remaining)
+		}
+	}
+}
+
+// Lock must be held
+func (b *MemoryBackend) tryAcquire(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	if _, ok := b.sessions[sessionID]; !ok {
+		return nil, 0, ErrInvalidSession
+	}
+
+	k, ok := b.keys[key]
+	if ok && k.session != "" && k.session != sessionID {
+		return nil, 0, nil
+	}
+
+	if !ok {
+		k = &memoryKey{lostCh: make(chan struct{})}
+		b.keys[key] = k
+	}
+	if k.session != sessionID {
+		k.acquiredAt = b.clock.Now()
+	}
+	b.index++
+	k.value = value
+	k.lockType = lockType
+	k.session = sessionID
+	k.modifyIndex = b.index
+	b.notify()
+
+	return k.lostCh, k.modifyIndex, nil
+}
+
+// Lock must be held
+func (b *MemoryBackend) invalidate(id string) {
+	s, ok := b.sessions[id]
+	if !ok {
+		return
+	}
+
+	delete(b.sessions, id)
+	close(s.doneCh)
+
+	for key, k := range b.keys {
+		if k.session == id {
+			delete(b.keys, key)
+			close(k.lostCh)
+		}
+	}
+
+	b.index++
+	b.notify()
+}
+
+// Lock must be held
+func (b *MemoryBackend) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Lock must be held
+func (b *MemoryBackend) list(prefix string) []KeyValue {
+	var kvs []KeyValue
+	for key, k := range b.keys {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, k.keyValue(key))
+		}
+	}
+
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+func (k *memoryKey) keyValue(key string) KeyValue {
+	return KeyValue{Key: key, Value: k.value, Session: k.session, Type: k.lockType, AcquiredAt: k.acquiredAt}
+}
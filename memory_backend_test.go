@@ -0,0 +1,262 @@
+package locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryBackend", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+	})
+
+	listKeys := func(prefix string) []locket.KeyValue {
+		kvs, _, err := backend.ListPrefix(prefix, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		return kvs
+	}
+
+	Describe("sessions", func() {
+		var (
+			sessionID string
+			lostCh    <-chan struct{}
+		)
+
+		BeforeEach(func() {
+			var err error
+			sessionID, err = backend.CreateSession("some-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			lostCh, _, err = backend.AcquireKey(sessionID, "some-key", []byte("some-value"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("deletes the session's keys when it is not renewed within its TTL", func() {
+			clock.WaitForWatcherAndIncrement(ttl)
+			Eventually(lostCh).Should(BeClosed())
+			Expect(listKeys("some-key")).To(BeEmpty())
+		})
+
+		Context("when the session is renewed", func() {
+			var (
+				doneCh   chan struct{}
+				renewErr chan error
+			)
+
+			BeforeEach(func() {
+				doneCh = make(chan struct{})
+				renewErr = make(chan error, 1)
+				go func() {
+					renewErr <- backend.RenewSession(sessionID, ttl, doneCh, nil)
+				}()
+			})
+
+			It("keeps the session alive past its TTL", func() {
+				for i := 0; i < 4; i++ {
+					clock.WaitForNWatchersAndIncrement(ttl/2, 2)
+				}
+
+				Consistently(lostCh).ShouldNot(BeClosed())
+				kvs := listKeys("some-key")
+				Expect(kvs).To(HaveLen(1))
+				Expect(kvs[0].Value).To(Equal([]byte("some-value")))
+				Expect(kvs[0].Session).To(Equal(sessionID))
+			})
+
+			It("destroys the session once done", func() {
+				close(doneCh)
+				Eventually(renewErr).Should(Receive(BeNil()))
+				Expect(lostCh).To(BeClosed())
+			})
+
+			It("fails when the session is destroyed", func() {
+				Expect(backend.DestroySession(sessionID)).To(Succeed())
+				Eventually(renewErr).Should(Receive(Equal(locket.ErrInvalidSession)))
+				Expect(lostCh).To(BeClosed())
+			})
+		})
+	})
+
+	Describe("AcquireKey", func() {
+		var (
+			sessionA, sessionB string
+			tokenA             uint64
+		)
+
+		BeforeEach(func() {
+			var err error
+			sessionA, err = backend.CreateSession("session-a", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			sessionB, err = backend.CreateSession("session-b", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, tokenA, err = backend.AcquireKey(sessionA, "some-key", []byte("a"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("blocks until the key is released", func() {
+			acquired := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				lostCh, tokenB, err := backend.AcquireKey(sessionB, "some-key", []byte("b"), locket.LockType, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lostCh).NotTo(BeNil())
+				Expect(tokenB).To(BeNumerically(">", tokenA))
+				close(acquired)
+			}()
+
+			Consistently(acquired).ShouldNot(BeClosed())
+
+			Expect(backend.DestroySession(sessionA)).To(Succeed())
+			Eventually(acquired).Should(BeClosed())
+			Expect(listKeys("some-key")[0].Value).To(Equal([]byte("b")))
+		})
+
+		It("returns a nil channel when stopped", func() {
+			stopCh := make(chan struct{})
+			close(stopCh)
+
+			lostCh, _, err := backend.AcquireKey(sessionB, "some-key", []byte("b"), locket.LockType, stopCh)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostCh).To(BeNil())
+		})
+
+		It("lets another session take a released key", func() {
+			lostCh, _, err := backend.TryAcquireKey(sessionB, "some-key", []byte("b"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostCh).To(BeNil())
+
+			Expect(backend.ReleaseKey(sessionA, "some-key")).To(Succeed())
+
+			lostCh, _, err = backend.TryAcquireKey(sessionB, "some-key", []byte("b"), locket.LockType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lostCh).NotTo(BeNil())
+		})
+
+		It("fails with an invalid session", func() {
+			_, _, err := backend.AcquireKey("bogus", "other-key", []byte("b"), locket.LockType, nil)
+			Expect(err).To(Equal(locket.ErrInvalidSession))
+		})
+	})
+
+	Describe("ListPrefix", func() {
+		It("returns nil when nothing exists under the prefix", func() {
+			Expect(listKeys("under")).To(BeNil())
+		})
+
+		It("blocks until the prefix changes", func() {
+			_, index, err := backend.ListPrefix("under", 0, time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			result := make(chan []locket.KeyValue, 1)
+			go func() {
+				defer GinkgoRecover()
+				kvs, _, err := backend.ListPrefix("under", index, time.Minute)
+				Expect(err).NotTo(HaveOccurred())
+				result <- kvs
+			}()
+
+			Consistently(result).ShouldNot(Receive())
+
+			sessionID, err := backend.CreateSession("some-session", ttl, true)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = backend.AcquireKey(sessionID, "under/here", []byte("value"), locket.LockType, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			var kvs []locket.KeyValue
+			Eventually(result).Should(Receive(&kvs))
+			Expect(kvs).To(HaveLen(1))
+			Expect(kvs[0].Key).To(Equal("under/here"))
+		})
+
+		It("returns once the wait time elapses", func() {
+			_, index, err := backend.ListPrefix("under", 0, time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			result := make(chan uint64, 1)
+			go func() {
+				defer GinkgoRecover()
+				_, newIndex, err := backend.ListPrefix("under", index, time.Minute)
+				Expect(err).NotTo(HaveOccurred())
+				result <- newIndex
+			}()
+
+			clock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(result).Should(Receive(Equal(index)))
+		})
+	})
+
+	Describe("running a Lock", func() {
+		var (
+			lockKey    string
+			logger     *lagertest.TestLogger
+			lockA      ifrit.Process
+			lockB      ifrit.Process
+			runnerA    locket.Lock
+			lockRunner locket.Lock
+		)
+
+		BeforeEach(func() {
+			lockKey = locket.LockSchemaPath("some-key")
+			logger = lagertest.NewTestLogger("locket")
+			metrics.Initialize(fake.NewFakeMetricSender(), nil)
+
+			runnerA = locket.NewLockWithBackend(logger, backend, lockKey, []byte("a"), clock, time.Second, ttl)
+			lockA = ifrit.Background(runnerA)
+			Eventually(lockA.Ready()).Should(BeClosed())
+
+			lockRunner = locket.NewLockWithBackend(logger, backend, lockKey, []byte("b"), clock, time.Second, ttl)
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(lockA)
+			ginkgomon.Kill(lockB)
+		})
+
+		It("waits for the holder to release the lock", func() {
+			lockB = ifrit.Background(lockRunner)
+			Consistently(lockB.Ready()).ShouldNot(BeClosed())
+
+			ginkgomon.Interrupt(lockA)
+			Eventually(lockB.Ready()).Should(BeClosed())
+			Expect(listKeys(lockKey)[0].Value).To(Equal([]byte("b")))
+		})
+
+		It("hands out increasing fencing tokens", func() {
+			tokenA := runnerA.FencingToken()
+			Expect(tokenA).NotTo(BeZero())
+
+			lockB = ifrit.Background(lockRunner)
+			ginkgomon.Interrupt(lockA)
+			Eventually(lockB.Ready()).Should(BeClosed())
+
+			Expect(runnerA.FencingToken()).To(BeZero())
+			Expect(lockRunner.FencingToken()).To(BeNumerically(">", tokenA))
+		})
+
+		It("loses the lock when the session is invalidated", func() {
+			kvs := listKeys(lockKey)
+			Expect(backend.DestroySession(kvs[0].Session)).To(Succeed())
+
+			Eventually(lockA.Wait()).Should(Receive(Equal(locket.ErrLockLost)))
+		})
+	})
+})
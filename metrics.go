@@ -0,0 +1,103 @@
+package locket
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/runtimeschema/metric"
+)
+
+//go:generate counterfeiter -o fakes/fake_metrics_emitter.go . MetricsEmitter
+
+// MetricsEmitter receives the metrics of the runners. It is called from
+// the runners' goroutines, so implementations must be safe for concurrent
+// use.
+type MetricsEmitter interface {
+	// LockHeld reports whether the lock on key is held, and for how long.
+	// Lock reports it on every change and every 30 seconds while held.
+	LockHeld(key string, held bool, uptime time.Duration)
+
+	// LockAttempted reports each attempt to acquire the lock on key; err is
+	// nil when it succeeded.
+	LockAttempted(key string, err error)
+
+	// LockAcquired reports how long it took to acquire the lock on key.
+	LockAcquired(key string, wait time.Duration)
+
+	// PresenceSet reports whether the presence on key is currently set.
+	PresenceSet(key string, set bool)
+
+	// DisappearancesObserved reports keys that disappeared under prefix.
+	DisappearancesObserved(prefix string, keys []string)
+
+	// WatchFailed reports a failed watch of a key or prefix.
+	WatchFailed(key string, err error)
+
+	// HeartbeatFailed reports a failed TTL health check update of a
+	// registered service.
+	HeartbeatFailed(service string, err error)
+}
+
+func WithMetricsEmitter(emitter MetricsEmitter) Option {
+	return func(o *runnerOptions) {
+		o.metrics = emitter
+	}
+}
+
+func (o runnerOptions) metricsOr(emitter MetricsEmitter) MetricsEmitter {
+	if o.metrics == nil {
+		return emitter
+	}
+	return o.metrics
+}
+
+// NoopMetricsEmitter drops every metric. Embed it to implement only some of
+// MetricsEmitter.
+type NoopMetricsEmitter struct{}
+
+func (NoopMetricsEmitter) LockHeld(key string, held bool, uptime time.Duration) {}
+func (NoopMetricsEmitter) LockAttempted(key string, err error)                  {}
+func (NoopMetricsEmitter) LockAcquired(key string, wait time.Duration)          {}
+func (NoopMetricsEmitter) PresenceSet(key string, set bool)                     {}
+func (NoopMetricsEmitter) DisappearancesObserved(prefix string, keys []string)  {}
+func (NoopMetricsEmitter) WatchFailed(key string, err error)                    {}
+func (NoopMetricsEmitter) HeartbeatFailed(service string, err error)            {}
+
+type dropsondeMetricsEmitter struct {
+	NoopMetricsEmitter
+	logger lager.Logger
+}
+
+// NewDropsondeMetricsEmitter sends LockHeld.<key> and
+// LockHeldDuration.<key> through dropsonde, with the slashes in the key
+// replaced by dashes. It is what Lock uses when no emitter is given, and it
+// ignores every other metric.
+func NewDropsondeMetricsEmitter(logger lager.Logger) MetricsEmitter {
+	return dropsondeMetricsEmitter{logger: logger}
+}
+
+func (e dropsondeMetricsEmitter) LockHeld(key string, held bool, uptime time.Duration) {
+	lockMetricName := strings.Replace(key, "/", "-", -1)
+	lockAcquiredMetric := metric.Metric("LockHeld." + lockMetricName)
+	lockUptimeMetric := metric.Duration("LockHeldDuration." + lockMetricName)
+
+	var acqVal int
+	if held {
+		acqVal = 1
+	}
+
+	e.logger.Debug("reemit-lock-uptime", lager.Data{"uptime": uptime,
+		"uptimeMetricName":       lockUptimeMetric,
+		"lockAcquiredMetricName": lockAcquiredMetric,
+	})
+	err := lockUptimeMetric.Send(uptime)
+	if err != nil {
+		e.logger.Error("failed-to-send-lock-uptime-metric", err)
+	}
+
+	err = lockAcquiredMetric.Send(acqVal)
+	if err != nil {
+		e.logger.Error("failed-to-send-lock-acquired-metric", err)
+	}
+}
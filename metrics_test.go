@@ -0,0 +1,130 @@
+package locket_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/fakes"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetricsEmitter", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+		emitter *fakes.FakeMetricsEmitter
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		emitter = &fakes.FakeMetricsEmitter{}
+	})
+
+	It("reports a lock being held and released", func() {
+		lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl,
+			locket.WithMetricsEmitter(emitter))
+		process := ifrit.Invoke(lock)
+
+		Expect(emitter.LockAttemptedCallCount()).To(Equal(1))
+		key, err := emitter.LockAttemptedArgsForCall(0)
+		Expect(key).To(Equal("some-key"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(emitter.LockAcquiredCallCount()).To(Equal(1))
+
+		Expect(emitter.LockHeldCallCount()).To(Equal(1))
+		key, held, _ := emitter.LockHeldArgsForCall(0)
+		Expect(key).To(Equal("some-key"))
+		Expect(held).To(BeTrue())
+
+		clock.WaitForWatcherAndIncrement(30 * time.Second)
+		Eventually(emitter.LockHeldCallCount).Should(Equal(2))
+		_, held, uptime := emitter.LockHeldArgsForCall(1)
+		Expect(held).To(BeTrue())
+		Expect(uptime).To(Equal(30 * time.Second))
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+		_, held, _ = emitter.LockHeldArgsForCall(emitter.LockHeldCallCount() - 1)
+		Expect(held).To(BeFalse())
+	})
+
+	It("reports a presence being set", func() {
+		presence := locket.NewPresenceWithBackend(logger, backend, "some-presence", []byte("a"), clock, time.Second, ttl,
+			locket.WithMetricsEmitter(emitter))
+
+		handle, err := presence.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(emitter.PresenceSetCallCount).Should(Equal(1))
+		key, set := emitter.PresenceSetArgsForCall(0)
+		Expect(key).To(Equal("some-presence"))
+		Expect(set).To(BeTrue())
+
+		handle.Release()
+		_, set = emitter.PresenceSetArgsForCall(emitter.PresenceSetCallCount() - 1)
+		Expect(set).To(BeFalse())
+	})
+
+	It("reports disappearances and failed watches", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		listing := newListingBackend(backend)
+		failing := &failingListBackend{Backend: listing, err: errors.New("boom")}
+		disappearances := locket.WatchDisappearancesWithBackend(ctx, logger, failing, "under", clock,
+			locket.WithMetricsEmitter(emitter), locket.WithBackoff(locket.ConstantBackoff(0)))
+
+		Eventually(emitter.WatchFailedCallCount).Should(Equal(1))
+		prefix, err := emitter.WatchFailedArgsForCall(0)
+		Expect(prefix).To(Equal("under"))
+		Expect(err).To(Equal(failing.err))
+
+		presence := locket.NewPresenceWithBackend(logger, backend, "under/here", []byte("a"), clock, time.Second, ttl)
+		handle, err := presence.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(listing.listed).Should(Receive(ContainElement(HaveField("Key", "under/here"))))
+		Expect(emitter.WatchFailedCallCount()).To(Equal(1))
+
+		handle.Release()
+		Eventually(disappearances).Should(Receive())
+		Expect(emitter.DisappearancesObservedCallCount()).To(Equal(1))
+		prefix, keys := emitter.DisappearancesObservedArgsForCall(0)
+		Expect(prefix).To(Equal("under"))
+		Expect(keys).To(Equal([]string{"under/here"}))
+	})
+})
+
+// failingListBackend fails the first ListPrefix.
+type failingListBackend struct {
+	locket.Backend
+	err error
+
+	lock   sync.Mutex
+	failed bool
+}
+
+func (b *failingListBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]locket.KeyValue, uint64, error) {
+	b.lock.Lock()
+	failed := b.failed
+	b.failed = true
+	b.lock.Unlock()
+
+	if !failed {
+		return nil, 0, b.err
+	}
+	return b.Backend.ListPrefix(prefix, waitIndex, waitTime)
+}
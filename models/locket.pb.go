@@ -0,0 +1,649 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v3.21.12
+// source: locket.proto
+
+package models
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Resource struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Resource) Reset() {
+	*x = Resource{}
+	mi := &file_locket_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resource) ProtoMessage() {}
+
+func (x *Resource) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resource.ProtoReflect.Descriptor instead.
+func (*Resource) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Resource) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Resource) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Resource) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Resource) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type LockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resource      *Resource              `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	TtlInSeconds  int64                  `protobuf:"varint,2,opt,name=ttl_in_seconds,json=ttlInSeconds,proto3" json:"ttl_in_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockRequest) Reset() {
+	*x = LockRequest{}
+	mi := &file_locket_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockRequest) ProtoMessage() {}
+
+func (x *LockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockRequest.ProtoReflect.Descriptor instead.
+func (*LockRequest) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LockRequest) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+func (x *LockRequest) GetTtlInSeconds() int64 {
+	if x != nil {
+		return x.TtlInSeconds
+	}
+	return 0
+}
+
+type LockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockResponse) Reset() {
+	*x = LockResponse{}
+	mi := &file_locket_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockResponse) ProtoMessage() {}
+
+func (x *LockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockResponse.ProtoReflect.Descriptor instead.
+func (*LockResponse) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LockResponse) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type LockAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resources     []*Resource            `protobuf:"bytes,1,rep,name=resources,proto3" json:"resources,omitempty"`
+	TtlInSeconds  int64                  `protobuf:"varint,2,opt,name=ttl_in_seconds,json=ttlInSeconds,proto3" json:"ttl_in_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockAllRequest) Reset() {
+	*x = LockAllRequest{}
+	mi := &file_locket_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockAllRequest) ProtoMessage() {}
+
+func (x *LockAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockAllRequest.ProtoReflect.Descriptor instead.
+func (*LockAllRequest) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LockAllRequest) GetResources() []*Resource {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *LockAllRequest) GetTtlInSeconds() int64 {
+	if x != nil {
+		return x.TtlInSeconds
+	}
+	return 0
+}
+
+type LockAllResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indexes       []int64                `protobuf:"varint,1,rep,packed,name=indexes,proto3" json:"indexes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockAllResponse) Reset() {
+	*x = LockAllResponse{}
+	mi := &file_locket_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockAllResponse) ProtoMessage() {}
+
+func (x *LockAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockAllResponse.ProtoReflect.Descriptor instead.
+func (*LockAllResponse) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LockAllResponse) GetIndexes() []int64 {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+type ReleaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resource      *Resource              `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseRequest) Reset() {
+	*x = ReleaseRequest{}
+	mi := &file_locket_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseRequest) ProtoMessage() {}
+
+func (x *ReleaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseRequest) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReleaseRequest) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+type ReleaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseResponse) Reset() {
+	*x = ReleaseResponse{}
+	mi := &file_locket_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseResponse) ProtoMessage() {}
+
+func (x *ReleaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseResponse) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{6}
+}
+
+type FetchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchRequest) Reset() {
+	*x = FetchRequest{}
+	mi := &file_locket_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchRequest) ProtoMessage() {}
+
+func (x *FetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchRequest.ProtoReflect.Descriptor instead.
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FetchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type FetchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resource      *Resource              `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchResponse) Reset() {
+	*x = FetchResponse{}
+	mi := &file_locket_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchResponse) ProtoMessage() {}
+
+func (x *FetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchResponse.ProtoReflect.Descriptor instead.
+func (*FetchResponse) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FetchResponse) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+type FetchAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchAllRequest) Reset() {
+	*x = FetchAllRequest{}
+	mi := &file_locket_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchAllRequest) ProtoMessage() {}
+
+func (x *FetchAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchAllRequest.ProtoReflect.Descriptor instead.
+func (*FetchAllRequest) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FetchAllRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type FetchAllResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resources     []*Resource            `protobuf:"bytes,1,rep,name=resources,proto3" json:"resources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchAllResponse) Reset() {
+	*x = FetchAllResponse{}
+	mi := &file_locket_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchAllResponse) ProtoMessage() {}
+
+func (x *FetchAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_locket_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchAllResponse.ProtoReflect.Descriptor instead.
+func (*FetchAllResponse) Descriptor() ([]byte, []int) {
+	return file_locket_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FetchAllResponse) GetResources() []*Resource {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+var File_locket_proto protoreflect.FileDescriptor
+
+const file_locket_proto_rawDesc = "" +
+	"\n" +
+	"\flocket.proto\x12\x06models\"\\\n" +
+	"\bResource\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\"a\n" +
+	"\vLockRequest\x12,\n" +
+	"\bresource\x18\x01 \x01(\v2\x10.models.ResourceR\bresource\x12$\n" +
+	"\x0ettl_in_seconds\x18\x02 \x01(\x03R\fttlInSeconds\"$\n" +
+	"\fLockResponse\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\"f\n" +
+	"\x0eLockAllRequest\x12.\n" +
+	"\tresources\x18\x01 \x03(\v2\x10.models.ResourceR\tresources\x12$\n" +
+	"\x0ettl_in_seconds\x18\x02 \x01(\x03R\fttlInSeconds\"+\n" +
+	"\x0fLockAllResponse\x12\x18\n" +
+	"\aindexes\x18\x01 \x03(\x03R\aindexes\">\n" +
+	"\x0eReleaseRequest\x12,\n" +
+	"\bresource\x18\x01 \x01(\v2\x10.models.ResourceR\bresource\"\x11\n" +
+	"\x0fReleaseResponse\" \n" +
+	"\fFetchRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"=\n" +
+	"\rFetchResponse\x12,\n" +
+	"\bresource\x18\x01 \x01(\v2\x10.models.ResourceR\bresource\"%\n" +
+	"\x0fFetchAllRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\"B\n" +
+	"\x10FetchAllResponse\x12.\n" +
+	"\tresources\x18\x01 \x03(\v2\x10.models.ResourceR\tresources2\xb2\x02\n" +
+	"\x06Locket\x123\n" +
+	"\x04Lock\x12\x13.models.LockRequest\x1a\x14.models.LockResponse\"\x00\x12<\n" +
+	"\aLockAll\x12\x16.models.LockAllRequest\x1a\x17.models.LockAllResponse\"\x00\x12<\n" +
+	"\aRelease\x12\x16.models.ReleaseRequest\x1a\x17.models.ReleaseResponse\"\x00\x126\n" +
+	"\x05Fetch\x12\x14.models.FetchRequest\x1a\x15.models.FetchResponse\"\x00\x12?\n" +
+	"\bFetchAll\x12\x17.models.FetchAllRequest\x1a\x18.models.FetchAllResponse\"\x00B%Z#code.cloudfoundry.org/locket/modelsb\x06proto3"
+
+var (
+	file_locket_proto_rawDescOnce sync.Once
+	file_locket_proto_rawDescData []byte
+)
+
+func file_locket_proto_rawDescGZIP() []byte {
+	file_locket_proto_rawDescOnce.Do(func() {
+		file_locket_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_locket_proto_rawDesc), len(file_locket_proto_rawDesc)))
+	})
+	return file_locket_proto_rawDescData
+}
+
+var file_locket_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_locket_proto_goTypes = []any{
+	(*Resource)(nil),         // 0: models.Resource
+	(*LockRequest)(nil),      // 1: models.LockRequest
+	(*LockResponse)(nil),     // 2: models.LockResponse
+	(*LockAllRequest)(nil),   // 3: models.LockAllRequest
+	(*LockAllResponse)(nil),  // 4: models.LockAllResponse
+	(*ReleaseRequest)(nil),   // 5: models.ReleaseRequest
+	(*ReleaseResponse)(nil),  // 6: models.ReleaseResponse
+	(*FetchRequest)(nil),     // 7: models.FetchRequest
+	(*FetchResponse)(nil),    // 8: models.FetchResponse
+	(*FetchAllRequest)(nil),  // 9: models.FetchAllRequest
+	(*FetchAllResponse)(nil), // 10: models.FetchAllResponse
+}
+var file_locket_proto_depIdxs = []int32{
+	0,  // 0: models.LockRequest.resource:type_name -> models.Resource
+	0,  // 1: models.LockAllRequest.resources:type_name -> models.Resource
+	0,  // 2: models.ReleaseRequest.resource:type_name -> models.Resource
+	0,  // 3: models.FetchResponse.resource:type_name -> models.Resource
+	0,  // 4: models.FetchAllResponse.resources:type_name -> models.Resource
+	1,  // 5: models.Locket.Lock:input_type -> models.LockRequest
+	3,  // 6: models.Locket.LockAll:input_type -> models.LockAllRequest
+	5,  // 7: models.Locket.Release:input_type -> models.ReleaseRequest
+	7,  // 8: models.Locket.Fetch:input_type -> models.FetchRequest
+	9,  // 9: models.Locket.FetchAll:input_type -> models.FetchAllRequest
+	2,  // 10: models.Locket.Lock:output_type -> models.LockResponse
+	4,  // 11: models.Locket.LockAll:output_type -> models.LockAllResponse
+	6,  // 12: models.Locket.Release:output_type -> models.ReleaseResponse
+	8,  // 13: models.Locket.Fetch:output_type -> models.FetchResponse
+	10, // 14: models.Locket.FetchAll:output_type -> models.FetchAllResponse
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_locket_proto_init() }
+func file_locket_proto_init() {
+	if File_locket_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_locket_proto_rawDesc), len(file_locket_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_locket_proto_goTypes,
+		DependencyIndexes: file_locket_proto_depIdxs,
+		MessageInfos:      file_locket_proto_msgTypes,
+	}.Build()
+	File_locket_proto = out.File
+	file_locket_proto_goTypes = nil
+	file_locket_proto_depIdxs = nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"code.cloudfoundry.org/locket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func NewResource(resource locket.Resource) *Resource {
+	return &Resource{
+		Key:   resource.Key,
+		Owner: resource.Owner,
+		Value: resource.Value,
+		Type:  resource.Type,
+	}
+}
+
+func (r *Resource) LocketResource() locket.Resource {
+	return locket.Resource{
+		Key:   r.GetKey(),
+		Owner: r.GetOwner(),
+		Value: r.GetValue(),
+		Type:  r.GetType(),
+	}
+}
+
+// ToStatusError maps store errors onto gRPC status codes so they survive the
+// round trip to the client.
+func ToStatusError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case locket.ErrLockCollision:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case locket.ErrResourceNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func FromStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return locket.ErrLockCollision
+	case codes.NotFound:
+		return locket.ErrResourceNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,206 @@
+package locket
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/hashicorp/consul/api"
+	"github.com/nu7hatch/gouuid"
+)
+
+// MultiLock holds a set of lock keys on one session, all or nothing. The whole
+// set is acquired in one step of the backend; when a key is taken, none of
+// the set is acquired and the MultiLock waits for that key before trying
+// again, so it never sits on part of its set. Losing any key destroys the
+// session, which gives up the rest of the set.
+type MultiLock struct {
+	backend Backend
+	consul  *Session
+	keys    []string
+	value   []byte
+
+	clock         clock.Clock
+	retryInterval time.Duration
+	options       runnerOptions
+	metrics       MetricsEmitter
+
+	logger lager.Logger
+	status *statusTracker
+}
+
+func NewMultiLock(
+	logger lager.Logger,
+	consulClient *api.Client,
+	lockKeys []string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) MultiLock {
+	return NewMultiLockWithBackend(logger, NewConsulAPIBackend(consulClient), lockKeys, lockValue, clock, retryInterval, lockTTL, opts...)
+}
+
+func NewMultiLockWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKeys []string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) MultiLock {
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		logger.Fatal("create-uuid-failed", err)
+	}
+
+	session, err := NewSessionNoChecksWithBackend(uuid.String(), lockTTL, backend)
+	if err != nil {
+		logger.Fatal("consul-session-failed", err)
+	}
+
+	keys := append([]string(nil), lockKeys...)
+	sort.Strings(keys)
+
+	options := newRunnerOptions(opts)
+
+	return MultiLock{
+		backend: backend,
+		consul:  session,
+		keys:    keys,
+		value:   lockValue,
+
+		clock:         clock,
+		retryInterval: retryInterval,
+		options:       options,
+		metrics:       options.metricsOr(NoopMetricsEmitter{}),
+
+		logger: logger,
+		status: newStatusTracker(clock),
+	}
+}
+
+// Status returns what the multi-lock is doing. It is safe to call from any
+// goroutine.
+func (l MultiLock) Status() Status {
+	return l.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (l MultiLock) StatusChanges() <-chan Status {
+	return l.status.changes
+}
+
+func (l MultiLock) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := l.logger.Session("multi-lock", lager.Data{"keys": l.keys, "value": string(l.value)})
+	logger.Info("starting")
+
+	defer func() {
+		l.consul.Destroy()
+		logger.Info("done")
+	}()
+
+	defer activity.addRunner("multi-lock", strings.Join(l.keys, ","), l.status)()
+	defer l.status.enter(StateStopped, "")
+
+	acquireErr := make(chan error, 1)
+
+	acquire := func(session *Session) {
+		logger.Info("acquiring-locks")
+		acquireErr <- l.acquireAll(logger, session)
+	}
+
+	var c <-chan time.Time
+	var failures int
+	retry := newRetrier(l.options.backoffOr(l.retryInterval))
+
+	start := l.clock.Now()
+	l.status.enter(StateAcquiring, "")
+	go acquire(l.consul)
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("shutting-down", lager.Data{"received-signal": sig})
+			return nil
+		case err := <-l.consul.Err():
+			if ready == nil {
+				logger.Error("lost-lock", err)
+				l.status.fail(StateLost, err)
+				return ErrLockLost
+			}
+
+			logger.Error("consul-error-without-lock", err)
+		case err := <-acquireErr:
+			for _, key := range l.keys {
+				l.metrics.LockAttempted(key, err)
+			}
+			if err != nil {
+				logger.Error("acquire-locks-failed", err, lager.Data{"retryable": IsRetryable(err)})
+				l.status.fail(StateRetrying, err)
+
+				failures++
+				if err := l.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-locks", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
+				break
+			}
+
+			logger.Info("acquire-locks-succeeded")
+			l.status.succeed(StateHolding, l.consul.ID())
+			for _, key := range l.keys {
+				l.metrics.LockAcquired(key, l.clock.Since(start))
+			}
+			close(ready)
+			ready = nil
+			c = nil
+			logger.Info("started")
+		case <-c:
+			logger.Info("retrying-acquiring-locks")
+			l.status.enter(StateRecreatingSession, l.consul.ID())
+			newSession, err := l.consul.Recreate()
+			if err != nil {
+				logger.Error("failed-to-recreate-session", err, lager.Data{"retryable": IsRetryable(err)})
+				l.status.fail(StateRetrying, err)
+
+				failures++
+				if err := l.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-locks", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
+			} else {
+				l.consul = newSession
+				l.status.enter(StateAcquiring, newSession.ID())
+				c = nil
+				go acquire(newSession)
+			}
+		}
+	}
+}
+
+func (l MultiLock) acquireAll(logger lager.Logger, session *Session) error {
+	for {
+		contended, err := session.TryAcquireLocks(l.keys, l.value)
+		if err != nil || contended == "" {
+			return err
+		}
+
+		logger.Info("waiting-for-contended-lock", lager.Data{"key": contended})
+		err = waitForVacantKey(l.backend, session, contended)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package locket_test
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+// singleKeyCountingBackend counts the single keys acquired and released
+// through it.
+type singleKeyCountingBackend struct {
+	locket.Backend
+
+	lock  sync.Mutex
+	count int
+}
+
+func (b *singleKeyCountingBackend) TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	b.count++
+	b.lock.Unlock()
+	return b.Backend.TryAcquireKey(sessionID, key, value, lockType)
+}
+
+func (b *singleKeyCountingBackend) ReleaseKey(sessionID, key string) error {
+	b.lock.Lock()
+	b.count++
+	b.lock.Unlock()
+	return b.Backend.ReleaseKey(sessionID, key)
+}
+
+func (b *singleKeyCountingBackend) calls() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.count
+}
+
+var _ = Describe("MultiLock", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock     *fakeclock.FakeClock
+		backend   *locket.MemoryBackend
+		logger    *lagertest.TestLogger
+		keyA      string
+		keyB      string
+		processes []ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		keyA = locket.LockSchemaPath("a")
+		keyB = locket.LockSchemaPath("b")
+		processes = nil
+	})
+
+	AfterEach(func() {
+		for _, process := range processes {
+			ginkgomon.Kill(process)
+		}
+	})
+
+	start := func(runner ifrit.Runner) ifrit.Process {
+		process := ifrit.Background(runner)
+		processes = append(processes, process)
+		return process
+	}
+
+	holder := func(key string) string {
+		kv, err := backend.GetKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		if kv == nil {
+			return ""
+		}
+		return kv.Session
+	}
+
+	It("holds every key on one session", func() {
+		multi := start(locket.NewMultiLockWithBackend(logger, backend, []string{keyB, keyA}, []byte("multi"), clock, time.Second, ttl))
+		Eventually(multi.Ready()).Should(BeClosed())
+
+		Expect(holder(keyA)).NotTo(BeEmpty())
+		Expect(holder(keyA)).To(Equal(holder(keyB)))
+	})
+
+	It("does not hold part of the set while a key is taken", func() {
+		lock := start(locket.NewLockWithBackend(logger, backend, keyB, []byte("single"), clock, time.Second, ttl))
+		Eventually(lock.Ready()).Should(BeClosed())
+
+		multi := start(locket.NewMultiLockWithBackend(logger, backend, []string{keyA, keyB}, []byte("multi"), clock, time.Second, ttl))
+		Eventually(logger).Should(Say("waiting-for-contended-lock"))
+		Consistently(multi.Ready()).ShouldNot(BeClosed())
+		Expect(holder(keyA)).To(BeEmpty())
+
+		ginkgomon.Interrupt(lock)
+		Eventually(multi.Ready()).Should(BeClosed())
+		Expect(holder(keyA)).To(Equal(holder(keyB)))
+	})
+
+	It("acquires the set without taking and giving back single keys", func() {
+		lock := start(locket.NewLockWithBackend(logger, backend, keyB, []byte("single"), clock, time.Second, ttl))
+		Eventually(lock.Ready()).Should(BeClosed())
+
+		counting := &singleKeyCountingBackend{Backend: backend}
+		multi := start(locket.NewMultiLockWithBackend(logger, counting, []string{keyA, keyB}, []byte("multi"), clock, time.Second, ttl))
+		Eventually(logger).Should(Say("waiting-for-contended-lock"))
+
+		ginkgomon.Interrupt(lock)
+		Eventually(multi.Ready()).Should(BeClosed())
+		Expect(counting.calls()).To(BeZero())
+	})
+
+	It("reports the loss of any key as the loss of the set", func() {
+		multi := start(locket.NewMultiLockWithBackend(logger, backend, []string{keyA, keyB}, []byte("multi"), clock, time.Second, ttl))
+		Eventually(multi.Ready()).Should(BeClosed())
+
+		sessionID := holder(keyB)
+		Expect(backend.ReleaseKey(sessionID, keyB)).To(Succeed())
+
+		Eventually(multi.Wait()).Should(Receive(Equal(locket.ErrLockLost)))
+		Expect(holder(keyA)).To(BeEmpty())
+	})
+})
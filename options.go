@@ -0,0 +1,77 @@
+package locket
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures a runner.
+type Option func(*runnerOptions)
+
+type runnerOptions struct {
+	retryPolicy RetryPolicy
+	backoff     Backoff
+	metrics     MetricsEmitter
+	lockHooks   LockHooks
+	standby     bool
+	leadership  chan<- LeadershipChange
+}
+
+func newRunnerOptions(opts []Option) runnerOptions {
+	var o runnerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RetryPolicy decides when a runner gives up on failed attempts instead of
+// retrying them every retry interval.
+type RetryPolicy struct {
+	// Retryable reports whether an error may go away on a later attempt.
+	// IsRetryable is used when it is nil.
+	Retryable func(error) bool
+
+	// MaxConsecutiveFailures gives up after that many failed attempts in a
+	// row. Zero retries forever.
+	MaxConsecutiveFailures int
+}
+
+// WithBackoff replaces the runner's fixed retry interval.
+func WithBackoff(backoff Backoff) Option {
+	return func(o *runnerOptions) {
+		o.backoff = backoff
+	}
+}
+
+func (o runnerOptions) backoffOr(retryInterval time.Duration) Backoff {
+	if o.backoff == nil {
+		return ConstantBackoff(retryInterval)
+	}
+	return o.backoff
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *runnerOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// check returns nil when the attempt that failed with err should be retried,
+// and the error to give up with otherwise.
+func (p RetryPolicy) check(err error, failures int) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	if !retryable(err) {
+		return err
+	}
+
+	if p.MaxConsecutiveFailures > 0 && failures >= p.MaxConsecutiveFailures {
+		return fmt.Errorf("giving up after %d consecutive failures: %w", failures, err)
+	}
+
+	return nil
+}
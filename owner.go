@@ -0,0 +1,102 @@
+package locket
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const ownerEnvelopeFormat = "locket-owner/v1"
+
+var processStartTime = time.Now()
+
+// OwnerInfo describes the process holding a lock or presence. Values encoded
+// with EncodeOwnerValue carry it next to the caller's own value, so that
+// anyone reading the key can tell who holds it.
+type OwnerInfo struct {
+	OwnerID          string            `json:"owner_id"`
+	Hostname         string            `json:"hostname,omitempty"`
+	PID              int               `json:"pid,omitempty"`
+	ProcessStartTime time.Time         `json:"process_start_time"`
+	AcquiredAt       time.Time         `json:"acquired_at"`
+	Version          string            `json:"version,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+}
+
+type ownerEnvelope struct {
+	Format string    `json:"locket_format"`
+	Owner  OwnerInfo `json:"owner"`
+	Value  []byte    `json:"value,omitempty"`
+}
+
+// NewOwnerInfo describes the current process.
+func NewOwnerInfo(ownerID, version string, labels map[string]string) OwnerInfo {
+	hostname, _ := os.Hostname()
+
+	return OwnerInfo{
+		OwnerID:          ownerID,
+		Hostname:         hostname,
+		PID:              os.Getpid(),
+		ProcessStartTime: processStartTime,
+		Version:          version,
+		Labels:           labels,
+	}
+}
+
+// EncodeOwnerValue wraps value in an envelope with the owner's metadata. Lock
+// and Presence stamp AcquiredAt into the envelope each time they acquire
+// their key.
+func EncodeOwnerValue(owner OwnerInfo, value []byte) ([]byte, error) {
+	return json.Marshal(ownerEnvelope{
+		Format: ownerEnvelopeFormat,
+		Owner:  owner,
+		Value:  value,
+	})
+}
+
+// DecodeOwnerValue unwraps a value written by EncodeOwnerValue. Any other
+// value is returned unchanged with a nil OwnerInfo.
+func DecodeOwnerValue(raw []byte) (*OwnerInfo, []byte) {
+	envelope, ok := decodeOwnerEnvelope(raw)
+	if !ok {
+		return nil, raw
+	}
+
+	return &envelope.Owner, envelope.Value
+}
+
+func decodeOwnerEnvelope(raw []byte) (ownerEnvelope, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return ownerEnvelope{}, false
+	}
+
+	var envelope ownerEnvelope
+	err := json.Unmarshal(raw, &envelope)
+	if err != nil || envelope.Format != ownerEnvelopeFormat {
+		return ownerEnvelope{}, false
+	}
+
+	return envelope, true
+}
+
+// recordAcquiredAt rewrites a key the session has just acquired with the
+// acquisition time in its owner envelope. Stamping the value before acquiring
+// would record when a standby started waiting instead.
+func recordAcquiredAt(logger lager.Logger, session *Session, key string, raw []byte, acquiredAt time.Time) {
+	envelope, ok := decodeOwnerEnvelope(raw)
+	if !ok {
+		return
+	}
+
+	envelope.Owner.AcquiredAt = acquiredAt
+	stamped, err := json.Marshal(envelope)
+	if err == nil {
+		err = session.backend.UpdateKey(session.ID(), key, stamped)
+	}
+	if err != nil {
+		logger.Error("failed-recording-acquired-at", err)
+	}
+}
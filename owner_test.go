@@ -0,0 +1,103 @@
+package locket_test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Owner values", func() {
+	var owner locket.OwnerInfo
+
+	BeforeEach(func() {
+		owner = locket.NewOwnerInfo("some-owner", "1.2.3", map[string]string{"az": "z1"})
+	})
+
+	It("describes the current process", func() {
+		hostname, err := os.Hostname()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(owner.Hostname).To(Equal(hostname))
+		Expect(owner.PID).To(Equal(os.Getpid()))
+		Expect(owner.ProcessStartTime).NotTo(BeZero())
+	})
+
+	It("round-trips the owner and the value", func() {
+		encoded, err := locket.EncodeOwnerValue(owner, []byte("some-value"))
+		Expect(err).NotTo(HaveOccurred())
+
+		decodedOwner, value := locket.DecodeOwnerValue(encoded)
+		Expect(decodedOwner).NotTo(BeNil())
+		Expect(decodedOwner.OwnerID).To(Equal("some-owner"))
+		Expect(decodedOwner.Version).To(Equal("1.2.3"))
+		Expect(decodedOwner.Labels).To(Equal(map[string]string{"az": "z1"}))
+		Expect(value).To(Equal([]byte("some-value")))
+	})
+
+	It("returns raw values unchanged", func() {
+		for _, raw := range [][]byte{[]byte("some-value"), []byte(`{"cell_id":"cell-1"}`), nil} {
+			decodedOwner, value := locket.DecodeOwnerValue(raw)
+			Expect(decodedOwner).To(BeNil())
+			Expect(value).To(Equal(raw))
+		}
+	})
+
+	It("is stamped with the acquire time by a Lock", func() {
+		clock := fakeclock.NewFakeClock(time.Now())
+		backend := locket.NewMemoryBackend(clock)
+		lockKey := locket.LockSchemaPath("some-key")
+
+		encoded, err := locket.EncodeOwnerValue(owner, []byte("some-value"))
+		Expect(err).NotTo(HaveOccurred())
+
+		lock := locket.NewLockWithBackend(lagertest.NewTestLogger("locket"), backend, lockKey, encoded, clock, time.Second, 10*time.Second)
+		process := ifrit.Background(lock)
+		defer ginkgomon.Kill(process)
+		Eventually(process.Ready()).Should(BeClosed())
+
+		holder, err := locket.FetchLockWithBackend(backend, lockKey)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holder.Value).To(Equal([]byte("some-value")))
+		Expect(holder.Owner.OwnerID).To(Equal("some-owner"))
+		Expect(holder.Owner.AcquiredAt.Equal(clock.Now())).To(BeTrue())
+	})
+
+	It("is stamped when a standby Lock acquires, not when it starts waiting", func() {
+		clock := fakeclock.NewFakeClock(time.Now())
+		backend := locket.NewMemoryBackend(clock)
+		logger := lagertest.NewTestLogger("locket")
+		lockKey := locket.LockSchemaPath("some-key")
+
+		other := locket.NewLockWithBackend(logger, backend, lockKey, []byte("other"), clock, time.Second, 10*time.Second)
+		otherHandle, err := other.Acquire(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		encoded, err := locket.EncodeOwnerValue(owner, []byte("some-value"))
+		Expect(err).NotTo(HaveOccurred())
+
+		lock := locket.NewLockWithBackend(logger, backend, lockKey, encoded, clock, time.Second, 10*time.Second)
+		process := ifrit.Background(lock)
+		defer ginkgomon.Kill(process)
+		Eventually(logger).Should(gbytes.Say("acquiring-lock"))
+
+		clock.Increment(3 * time.Second)
+		otherHandle.Release()
+		Eventually(process.Ready()).Should(BeClosed())
+
+		kv, err := backend.GetKey(lockKey)
+		Expect(err).NotTo(HaveOccurred())
+		decodedOwner, value := locket.DecodeOwnerValue(kv.Value)
+		Expect(value).To(Equal([]byte("some-value")))
+		Expect(decodedOwner.AcquiredAt.Equal(clock.Now())).To(BeTrue())
+	})
+})
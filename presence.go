@@ -1,6 +1,7 @@
 package locket
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -17,8 +18,10 @@ type Presence struct {
 
 	clock         clock.Clock
 	retryInterval time.Duration
+	options       runnerOptions
 
 	logger lager.Logger
+	status *statusTracker
 }
 
 func NewPresence(
@@ -29,13 +32,27 @@ func NewPresence(
 	clock clock.Clock,
 	retryInterval time.Duration,
 	lockTTL time.Duration,
+	opts ...Option,
+) Presence {
+	return NewPresenceWithBackend(logger, NewConsulBackend(consulClient), lockKey, lockValue, clock, retryInterval, lockTTL, opts...)
+}
+
+func NewPresenceWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
 ) Presence {
 	uuid, err := uuid.NewV4()
 	if err != nil {
 		logger.Fatal("create-uuid-failed", err)
 	}
 
-	session, err := NewSessionNoChecks(uuid.String(), lockTTL, consulClient)
+	session, err := NewSessionNoChecksWithBackend(uuid.String(), lockTTL, backend)
 	if err != nil {
 		logger.Fatal("consul-session-failed", err)
 	}
@@ -47,19 +64,115 @@ func NewPresence(
 
 		clock:         clock,
 		retryInterval: retryInterval,
+		options:       newRunnerOptions(opts),
 
 		logger: logger,
+		status: newStatusTracker(clock),
+	}
+}
+
+// Status returns what the presence is doing. It is safe to call from any
+// goroutine.
+func (p Presence) Status() Status {
+	return p.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (p Presence) StatusChanges() <-chan Status {
+	return p.status.changes
+}
+
+// PresenceHandle is a presence kept alive in the background until Release.
+// Failed receives an error if the retry policy gives up on it first.
+type PresenceHandle struct {
+	stop   chan struct{}
+	done   chan struct{}
+	failed chan error
+}
+
+func (h *PresenceHandle) Failed() <-chan error {
+	return h.failed
+}
+
+// Release stops maintaining the presence and removes it. It is safe to call
+// more than once.
+func (h *PresenceHandle) Release() {
+	select {
+	case <-h.stop:
+	default:
+		close(h.stop)
+	}
+	<-h.done
+}
+
+// Acquire blocks until the presence is first set or ctx is done. Once set,
+// the presence is re-established in the background whenever it is lost,
+// until the handle is released.
+func (p Presence) Acquire(ctx context.Context) (*PresenceHandle, error) {
+	logger := p.logger.Session("presence", lager.Data{"key": p.key, "value": string(p.value)})
+
+	set := make(chan struct{})
+	handle := p.start(logger, set)
+
+	select {
+	case <-set:
+		return handle, nil
+	case err := <-handle.Failed():
+		return nil, err
+	case <-ctx.Done():
+		handle.Release()
+		return nil, ctx.Err()
 	}
 }
 
 func (p Presence) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	logger := p.logger.Session("presence", lager.Data{"key": p.key, "value": string(p.value)})
 	logger.Info("starting")
+	defer logger.Info("done")
+
+	defer activity.addRunner("presence", p.key, p.status)()
+
+	handle := p.start(logger, ready)
+	defer handle.Release()
+
+	select {
+	case sig := <-signals:
+		logger.Info("shutting-down", lager.Data{"received-signal": sig})
+		return nil
+	case err := <-handle.Failed():
+		return err
+	}
+}
+
+// start maintains the presence until the returned handle is released, and
+// closes set the first time the presence is set.
+func (p Presence) start(logger lager.Logger, set chan<- struct{}) *PresenceHandle {
+	handle := &PresenceHandle{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		failed: make(chan error, 1),
+	}
+
+	go func() {
+		defer close(handle.done)
+		if err := p.maintain(logger, handle.stop, set); err != nil {
+			handle.failed <- err
+		}
+	}()
+
+	return handle
+}
+
+func (p Presence) maintain(logger lager.Logger, stop <-chan struct{}, set chan<- struct{}) error {
+	session := p.consul.clone()
+	metrics := p.options.metricsOr(NoopMetricsEmitter{})
 
 	defer func() {
 		logger.Info("cleaning-up")
-		p.consul.Destroy()
-		logger.Info("done")
+		session.Destroy()
+		metrics.PresenceSet(p.key, false)
+		p.status.enter(StateStopped, "")
 	}()
 
 	type presenceResult struct {
@@ -71,66 +184,96 @@ func (p Presence) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	setPresence := func(session *Session) {
 		logger.Info("setting-presence")
 		presenceLost, err := session.SetPresence(p.key, p.value)
+		if err == nil {
+			recordAcquiredAt(logger, session, p.key, p.value, p.clock.Now())
+		}
 		presenceCh <- presenceResult{presenceLost, err}
 	}
 
 	var retryTimer <-chan time.Time
 	var presenceLost <-chan string
+	var failures int
+	retry := newRetrier(p.options.backoffOr(p.retryInterval))
 
-	go setPresence(p.consul)
+	p.status.enter(StateAcquiring, "")
+	go setPresence(session)
 
 	logger.Info("started")
 
-	readyChanClosed := false
-
 	for {
 		select {
-		case sig := <-signals:
-			logger.Info("shutting-down", lager.Data{"received-signal": sig})
-
+		case <-stop:
 			return nil
-		case err := <-p.consul.Err():
+		case err := <-session.Err():
 			var data lager.Data
 			if err != nil {
 				data = lager.Data{"err": err.Error()}
 			}
 			logger.Info("consul-error", data)
+			metrics.PresenceSet(p.key, false)
+			if err != nil {
+				p.status.fail(StateRetrying, err)
+			} else {
+				p.status.enter(StateRetrying, "")
+			}
 
 			presenceLost = nil
-			retryTimer = p.clock.NewTimer(p.retryInterval).C()
+			retryTimer = p.clock.NewTimer(retry.next()).C()
 		case result := <-presenceCh:
 			if result.err == nil {
-				if !readyChanClosed {
-					close(ready)
-					readyChanClosed = true
+				if set != nil {
+					close(set)
+					set = nil
 				}
 				logger.Info("succeeded-setting-presence")
+				metrics.PresenceSet(p.key, true)
+				p.status.succeed(StateHolding, session.ID())
 
 				retryTimer = nil
 				presenceLost = result.presenceLost
+				failures = 0
+				retry.reset()
 			} else {
-				logger.Error("failed-setting-presence", result.err)
+				logger.Error("failed-setting-presence", result.err, lager.Data{"retryable": IsRetryable(result.err)})
+				p.status.fail(StateRetrying, result.err)
+
+				failures++
+				if err := p.options.retryPolicy.check(result.err, failures); err != nil {
+					logger.Error("giving-up-setting-presence", err, lager.Data{"failures": failures})
+					return err
+				}
 
-				retryTimer = p.clock.NewTimer(p.retryInterval).C()
+				retryTimer = p.clock.NewTimer(retry.next()).C()
 			}
 		case <-presenceLost:
 			logger.Info("presence-lost")
+			metrics.PresenceSet(p.key, false)
+			p.status.enter(StateRetrying, "")
 
 			presenceLost = nil
-			retryTimer = p.clock.NewTimer(p.retryInterval).C()
+			retryTimer = p.clock.NewTimer(retry.next()).C()
 		case <-retryTimer:
 			logger.Info("recreating-session")
+			p.status.enter(StateRecreatingSession, session.ID())
 
 			presenceLost = nil
-			newSession, err := p.consul.Recreate()
+			newSession, err := session.Recreate()
 			if err != nil {
-				logger.Error("failed-recreating-session", err)
+				logger.Error("failed-recreating-session", err, lager.Data{"retryable": IsRetryable(err)})
+				p.status.fail(StateRetrying, err)
+
+				failures++
+				if err := p.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-setting-presence", err, lager.Data{"failures": failures})
+					return err
+				}
 
-				retryTimer = p.clock.NewTimer(p.retryInterval).C()
+				retryTimer = p.clock.NewTimer(retry.next()).C()
 			} else {
 				logger.Info("succeeded-recreating-session")
 
-				p.consul = newSession
+				session = newSession
+				p.status.enter(StateAcquiring, session.ID())
 				retryTimer = nil
 				go setPresence(newSession)
 			}
@@ -0,0 +1,146 @@
+// Package prometheus reports the metrics of the locket runners to
+// Prometheus.
+package prometheus
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/locket"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "locket"
+
+// Emitter is a locket.MetricsEmitter that is also a prometheus.Collector.
+// Pass it to the runners with locket.WithMetricsEmitter and register it with
+// a prometheus.Registerer.
+type Emitter struct {
+	lockHeld            *prom.GaugeVec
+	lockHeldDuration    *prom.GaugeVec
+	lockAttempts        *prom.CounterVec
+	lockFailures        *prom.CounterVec
+	lockAcquireDuration *prom.HistogramVec
+	presenceSet         *prom.GaugeVec
+	disappearances      *prom.CounterVec
+	watchErrors         *prom.CounterVec
+	heartbeatFailures   *prom.CounterVec
+}
+
+var _ locket.MetricsEmitter = (*Emitter)(nil)
+var _ prom.Collector = (*Emitter)(nil)
+
+func NewEmitter() *Emitter {
+	return &Emitter{
+		lockHeld: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "lock_held",
+			Help:      "Whether the lock is held (1) or not (0).",
+		}, []string{"key"}),
+		lockHeldDuration: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "lock_held_duration_seconds",
+			Help:      "How long the lock has been held.",
+		}, []string{"key"}),
+		lockAttempts: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquisition_attempts_total",
+			Help:      "Attempts to acquire the lock.",
+		}, []string{"key"}),
+		lockFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquisition_failures_total",
+			Help:      "Failed attempts to acquire the lock.",
+		}, []string{"key"}),
+		lockAcquireDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lock_acquire_duration_seconds",
+			Help:      "Time taken to acquire the lock.",
+			Buckets:   []float64{.01, .1, 1, 5, 10, 30, 60, 300, 900, 3600},
+		}, []string{"key"}),
+		presenceSet: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "presence_set",
+			Help:      "Whether the presence is set (1) or not (0).",
+		}, []string{"key"}),
+		disappearances: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "disappearances_total",
+			Help:      "Keys seen disappearing under the prefix.",
+		}, []string{"prefix"}),
+		watchErrors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "watch_errors_total",
+			Help:      "Failed watches of the key or prefix.",
+		}, []string{"key"}),
+		heartbeatFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "registration_heartbeat_failures_total",
+			Help:      "Failed TTL health check updates of the registered service.",
+		}, []string{"service"}),
+	}
+}
+
+func (e *Emitter) collectors() []prom.Collector {
+	return []prom.Collector{
+		e.lockHeld,
+		e.lockHeldDuration,
+		e.lockAttempts,
+		e.lockFailures,
+		e.lockAcquireDuration,
+		e.presenceSet,
+		e.disappearances,
+		e.watchErrors,
+		e.heartbeatFailures,
+	}
+}
+
+func (e *Emitter) Describe(ch chan<- *prom.Desc) {
+	for _, c := range e.collectors() {
+		c.Describe(ch)
+	}
+}
+
+func (e *Emitter) Collect(ch chan<- prom.Metric) {
+	for _, c := range e.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (e *Emitter) LockHeld(key string, held bool, uptime time.Duration) {
+	e.lockHeld.WithLabelValues(key).Set(boolValue(held))
+	e.lockHeldDuration.WithLabelValues(key).Set(uptime.Seconds())
+}
+
+func (e *Emitter) LockAttempted(key string, err error) {
+	e.lockAttempts.WithLabelValues(key).Inc()
+	if err != nil {
+		e.lockFailures.WithLabelValues(key).Inc()
+	}
+}
+
+func (e *Emitter) LockAcquired(key string, wait time.Duration) {
+	e.lockAcquireDuration.WithLabelValues(key).Observe(wait.Seconds())
+}
+
+func (e *Emitter) PresenceSet(key string, set bool) {
+	e.presenceSet.WithLabelValues(key).Set(boolValue(set))
+}
+
+func (e *Emitter) DisappearancesObserved(prefix string, keys []string) {
+	e.disappearances.WithLabelValues(prefix).Add(float64(len(keys)))
+}
+
+func (e *Emitter) WatchFailed(key string, err error) {
+	e.watchErrors.WithLabelValues(key).Inc()
+}
+
+func (e *Emitter) HeartbeatFailed(service string, err error) {
+	e.heartbeatFailures.WithLabelValues(service).Inc()
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package prometheus_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/locket/prometheus"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Emitter", func() {
+	var (
+		emitter  *prometheus.Emitter
+		registry *prom.Registry
+	)
+
+	BeforeEach(func() {
+		emitter = prometheus.NewEmitter()
+		registry = prom.NewRegistry()
+		Expect(registry.Register(emitter)).To(Succeed())
+	})
+
+	gather := func(expected string, names ...string) error {
+		return testutil.GatherAndCompare(registry, strings.NewReader(expected), names...)
+	}
+
+	It("reports locks", func() {
+		emitter.LockAttempted("some-key", errors.New("boom"))
+		emitter.LockAttempted("some-key", nil)
+		emitter.LockAcquired("some-key", 2*time.Second)
+		emitter.LockHeld("some-key", true, 30*time.Second)
+
+		Expect(gather(`
+# HELP locket_lock_held Whether the lock is held (1) or not (0).
+# TYPE locket_lock_held gauge
+locket_lock_held{key="some-key"} 1
+# HELP locket_lock_held_duration_seconds How long the lock has been held.
+# TYPE locket_lock_held_duration_seconds gauge
+locket_lock_held_duration_seconds{key="some-key"} 30
+# HELP locket_lock_acquisition_attempts_total Attempts to acquire the lock.
+# TYPE locket_lock_acquisition_attempts_total counter
+locket_lock_acquisition_attempts_total{key="some-key"} 2
+# HELP locket_lock_acquisition_failures_total Failed attempts to acquire the lock.
+# TYPE locket_lock_acquisition_failures_total counter
+locket_lock_acquisition_failures_total{key="some-key"} 1
+`,
+			"locket_lock_held",
+			"locket_lock_held_duration_seconds",
+			"locket_lock_acquisition_attempts_total",
+			"locket_lock_acquisition_failures_total",
+		)).To(Succeed())
+
+		Expect(testutil.CollectAndCount(emitter, "locket_lock_acquire_duration_seconds")).To(Equal(1))
+	})
+
+	It("reports presences, watchers and registrations", func() {
+		emitter.PresenceSet("some-presence", true)
+		emitter.DisappearancesObserved("some-prefix", []string{"a", "b"})
+		emitter.WatchFailed("some-prefix", errors.New("boom"))
+		emitter.HeartbeatFailed("some-service", errors.New("boom"))
+
+		Expect(gather(`
+# HELP locket_presence_set Whether the presence is set (1) or not (0).
+# TYPE locket_presence_set gauge
+locket_presence_set{key="some-presence"} 1
+# HELP locket_disappearances_total Keys seen disappearing under the prefix.
+# TYPE locket_disappearances_total counter
+locket_disappearances_total{prefix="some-prefix"} 2
+# HELP locket_watch_errors_total Failed watches of the key or prefix.
+# TYPE locket_watch_errors_total counter
+locket_watch_errors_total{key="some-prefix"} 1
+# HELP locket_registration_heartbeat_failures_total Failed TTL health check updates of the registered service.
+# TYPE locket_registration_heartbeat_failures_total counter
+locket_registration_heartbeat_failures_total{service="some-service"} 1
+`,
+			"locket_presence_set",
+			"locket_disappearances_total",
+			"locket_watch_errors_total",
+			"locket_registration_heartbeat_failures_total",
+		)).To(Succeed())
+	})
+})
@@ -18,6 +18,8 @@ type registrationRunner struct {
 	consulClient  consuladapter.Client
 	retryInterval time.Duration
 	clock         clock.Clock
+	options       runnerOptions
+	status        *statusTracker
 }
 
 func NewRegistrationRunner(
@@ -26,6 +28,7 @@ func NewRegistrationRunner(
 	consulClient consuladapter.Client,
 	retryInterval time.Duration,
 	clock clock.Clock,
+	opts ...Option,
 ) *registrationRunner {
 	return &registrationRunner{
 		logger:        logger,
@@ -33,9 +36,23 @@ func NewRegistrationRunner(
 		consulClient:  consulClient,
 		retryInterval: retryInterval,
 		clock:         clock,
+		options:       newRunnerOptions(opts),
+		status:        newStatusTracker(clock),
 	}
 }
 
+// Status returns what the runner is doing. It is safe to call from any
+// goroutine.
+func (r *registrationRunner) Status() Status {
+	return r.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (r *registrationRunner) StatusChanges() <-chan Status {
+	return r.status.changes
+}
+
 func (r *registrationRunner) validateRegistration() error {
 	if r.registration.Checks != nil && len(r.registration.Checks) != 0 {
 		// Implementing multiple service checks involves some nuance
@@ -52,16 +69,21 @@ func (r *registrationRunner) Run(signals <-chan os.Signal, ready chan<- struct{}
 	logger.Info("starting", lager.Data{"registration": r.registration})
 	defer logger.Info("finished")
 
+	defer activity.addRunner("registration", r.unregisterID(), r.status)()
+	defer r.status.enter(StateStopped, "")
+
 	// Fail early if the registration is invalid
 	err := r.validateRegistration()
 	if err != nil {
 		logger.Error("failed-invalid-service-registration", err)
+		r.status.fail(StateStopped, err)
 		return err
 	}
 
 	pollInterval, err := r.calculatePollInterval()
 	if err != nil {
 		logger.Error("failed-invalid-poll-interval", err)
+		r.status.fail(StateStopped, err)
 		return err
 	}
 
@@ -73,6 +95,7 @@ func (r *registrationRunner) Run(signals <-chan os.Signal, ready chan<- struct{}
 	}
 
 	retryTimer := r.clock.NewTimer(0)
+	retry := newRetrier(r.options.backoffOr(r.retryInterval))
 
 	for {
 		select {
@@ -84,9 +107,11 @@ func (r *registrationRunner) Run(signals <-chan os.Signal, ready chan<- struct{}
 		case err := <-errChan:
 			if err != nil {
 				logger.Error("failed-registering-service", err)
-				retryTimer.Reset(r.retryInterval)
+				r.status.fail(StateRetrying, err)
+				retryTimer.Reset(retry.next())
 			} else {
 				logger.Info("succeeded-registering-service")
+				r.status.succeed(StateRegistered, "")
 				retryTimer.Stop()
 				agent.PassTTL(r.checkID(), "")
 				close(ready)
@@ -170,14 +195,18 @@ func (r *registrationRunner) pollUntilSignaled(logger lager.Logger, interval tim
 			err := agent.PassTTL(checkID, "")
 			if err != nil {
 				logger.Error("failed-healthcheck-in-consul", err)
+				r.options.metricsOr(NoopMetricsEmitter{}).HeartbeatFailed(r.registration.Name, err)
+				r.status.fail(StateRetrying, err)
 				go register()
 			}
 			timer.Reset(interval)
 		case err := <-errChan:
 			if err != nil {
 				logger.Error("failed-registering-service", err)
+				r.status.fail(StateRetrying, err)
 			} else {
 				logger.Info("succeeded-registering-service")
+				r.status.succeed(StateRegistered, "")
 			}
 			timer.Reset(interval)
 		}
@@ -1,8 +1,10 @@
 package locket_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http/httptest"
 	"os"
 	"time"
 
@@ -295,6 +297,24 @@ var _ = Describe("Service Registration Unit Tests", func() {
 			Eventually(agent.ServiceRegisterCallCount()).Should(Equal(2))
 		})
 
+		It("is listed by the debug handler until it stops", func() {
+			Eventually(registrationProcess.Ready()).Should(BeClosed())
+
+			runners := func() []locket.DebugRunner {
+				recorder := httptest.NewRecorder()
+				locket.NewDebugHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+				var info locket.DebugInfo
+				Expect(json.NewDecoder(recorder.Body).Decode(&info)).To(Succeed())
+				return info.Runners
+			}
+
+			registered := And(HaveField("Kind", "registration"), HaveField("Key", registration.ID))
+			Expect(runners()).To(ContainElement(And(registered, HaveField("State", locket.StateRegistered))))
+
+			ginkgomon.Kill(registrationProcess)
+			Expect(runners()).NotTo(ContainElement(registered))
+		})
+
 		Context("deregistering the service", func() {
 			It("deregisters the service after being signalled", func() {
 				Expect(agent.ServiceDeregisterCallCount()).Should(Equal(0))
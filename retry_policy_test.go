@@ -0,0 +1,134 @@
+package locket_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+type failingBackend struct {
+	locket.Backend
+	err error
+}
+
+func (b failingBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	return nil, 0, b.err
+}
+
+func (b failingBackend) TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	return nil, 0, b.err
+}
+
+func (b failingBackend) AcquireKeys(sessionID string, kvs []locket.KeyValue) ([]<-chan struct{}, string, error) {
+	return nil, "", b.err
+}
+
+var _ = Describe("RetryPolicy", func() {
+	const (
+		retryInterval = time.Second
+		ttl           = 10 * time.Second
+	)
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend failingBackend
+		logger  *lagertest.TestLogger
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = failingBackend{Backend: locket.NewMemoryBackend(clock)}
+		logger = lagertest.NewTestLogger("locket")
+	})
+
+	AfterEach(func() {
+		if process != nil {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		}
+	})
+
+	Context("when a lock fails with a fatal error", func() {
+		BeforeEach(func() {
+			backend.err = &locket.BackendError{Kind: locket.ErrPermissionDenied, Err: errors.New("Permission denied")}
+		})
+
+		It("returns the error from Run", func() {
+			lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, retryInterval, ttl)
+			process = ifrit.Background(lock)
+
+			var err error
+			Eventually(process.Wait()).Should(Receive(&err))
+			Expect(errors.Is(err, locket.ErrPermissionDenied)).To(BeTrue())
+			process = nil
+		})
+
+		It("returns the error from the Run of a semaphore, a write lock and a multi-lock", func() {
+			runners := []ifrit.Runner{
+				locket.NewSemaphoreWithBackend(logger, backend, "some-prefix", 1, []byte("a"), clock, retryInterval, ttl),
+				locket.NewWriteLockWithBackend(logger, backend, "some-key", []byte("a"), clock, retryInterval, ttl),
+				locket.NewMultiLockWithBackend(logger, backend, []string{"a", "b"}, []byte("a"), clock, retryInterval, ttl),
+			}
+
+			for _, runner := range runners {
+				var err error
+				Eventually(ifrit.Background(runner).Wait()).Should(Receive(&err))
+				Expect(errors.Is(err, locket.ErrPermissionDenied)).To(BeTrue())
+			}
+		})
+
+		It("retries anyway when the policy says so", func() {
+			lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, retryInterval, ttl,
+				locket.WithRetryPolicy(locket.RetryPolicy{Retryable: func(error) bool { return true }}))
+			process = ifrit.Background(lock)
+
+			Consistently(process.Wait()).ShouldNot(Receive())
+		})
+	})
+
+	Context("when failures are capped", func() {
+		BeforeEach(func() {
+			backend.err = errors.New("boom")
+		})
+
+		It("gives up on a lock after that many failures in a row", func() {
+			lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, retryInterval, ttl,
+				locket.WithRetryPolicy(locket.RetryPolicy{MaxConsecutiveFailures: 2}))
+			process = ifrit.Background(lock)
+
+			Eventually(logger).Should(Say("acquire-lock-failed"))
+			Consistently(process.Wait()).ShouldNot(Receive())
+
+			clock.WaitForWatcherAndIncrement(retryInterval)
+
+			var err error
+			Eventually(process.Wait()).Should(Receive(&err))
+			Expect(errors.Is(err, backend.err)).To(BeTrue())
+			process = nil
+		})
+
+		It("gives up on a presence after that many failures in a row", func() {
+			presence := locket.NewPresenceWithBackend(logger, backend, "some-key", []byte("a"), clock, retryInterval, ttl,
+				locket.WithRetryPolicy(locket.RetryPolicy{MaxConsecutiveFailures: 2}))
+			process = ifrit.Background(presence)
+
+			Eventually(logger).Should(Say("failed-setting-presence"))
+			clock.WaitForWatcherAndIncrement(retryInterval)
+
+			var err error
+			Eventually(process.Wait()).Should(Receive(&err))
+			Expect(errors.Is(err, backend.err)).To(BeTrue())
+			process = nil
+		})
+	})
+})
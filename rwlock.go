@@ -0,0 +1,328 @@
+package locket
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"code.cloudfoundry.org/lager"
+	"github.com/nu7hatch/gouuid"
+)
+
+// RWLock is one side of a reader/writer lock under lockKey. A writer holds
+// lockKey/writer and then waits for the readers under lockKey/readers/ to
+// leave. A reader only registers while there is no writer, so a waiting writer
+// keeps new readers out and cannot be starved by them.
+type RWLock struct {
+	backend Backend
+	consul  *Session
+	key     string
+	value   []byte
+	writer  bool
+
+	clock         clock.Clock
+	retryInterval time.Duration
+	options       runnerOptions
+	metrics       MetricsEmitter
+
+	logger lager.Logger
+	status *statusTracker
+}
+
+func NewReadLock(
+	logger lager.Logger,
+	consulClient consuladapter.Client,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) RWLock {
+	return newRWLock(logger, NewConsulBackend(consulClient), lockKey, lockValue, false, clock, retryInterval, lockTTL, opts...)
+}
+
+func NewWriteLock(
+	logger lager.Logger,
+	consulClient consuladapter.Client,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) RWLock {
+	return newRWLock(logger, NewConsulBackend(consulClient), lockKey, lockValue, true, clock, retryInterval, lockTTL, opts...)
+}
+
+func NewReadLockWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) RWLock {
+	return newRWLock(logger, backend, lockKey, lockValue, false, clock, retryInterval, lockTTL, opts...)
+}
+
+func NewWriteLockWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	lockValue []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) RWLock {
+	return newRWLock(logger, backend, lockKey, lockValue, true, clock, retryInterval, lockTTL, opts...)
+}
+
+func newRWLock(
+	logger lager.Logger,
+	backend Backend,
+	lockKey string,
+	lockValue []byte,
+	writer bool,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	lockTTL time.Duration,
+	opts ...Option,
+) RWLock {
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		logger.Fatal("create-uuid-failed", err)
+	}
+
+	session, err := NewSessionNoChecksWithBackend(uuid.String(), lockTTL, backend)
+	if err != nil {
+		logger.Fatal("consul-session-failed", err)
+	}
+
+	options := newRunnerOptions(opts)
+
+	return RWLock{
+		backend: backend,
+		consul:  session,
+		key:     lockKey,
+		value:   lockValue,
+		writer:  writer,
+
+		clock:         clock,
+		retryInterval: retryInterval,
+		options:       options,
+		metrics:       options.metricsOr(NoopMetricsEmitter{}),
+
+		logger: logger,
+		status: newStatusTracker(clock),
+	}
+}
+
+// Status returns what the lock is doing. It is safe to call from any
+// goroutine.
+func (l RWLock) Status() Status {
+	return l.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (l RWLock) StatusChanges() <-chan Status {
+	return l.status.changes
+}
+
+func (l RWLock) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	mode := "read"
+	if l.writer {
+		mode = "write"
+	}
+
+	logger := l.logger.Session("rwlock", lager.Data{"key": l.key, "mode": mode, "value": string(l.value)})
+	logger.Info("starting")
+
+	defer func() {
+		l.consul.Destroy()
+		logger.Info("done")
+	}()
+
+	defer activity.addRunner("rwlock", l.key, l.status)()
+	defer l.status.enter(StateStopped, "")
+
+	acquireErr := make(chan error, 1)
+
+	acquire := func(session *Session) {
+		logger.Info("acquiring-lock")
+		if l.writer {
+			acquireErr <- l.acquireWrite(session)
+		} else {
+			acquireErr <- l.acquireRead(session)
+		}
+	}
+
+	var c <-chan time.Time
+	var failures int
+	retry := newRetrier(l.options.backoffOr(l.retryInterval))
+
+	start := l.clock.Now()
+	l.status.enter(StateAcquiring, "")
+	go acquire(l.consul)
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("shutting-down", lager.Data{"received-signal": sig})
+			return nil
+		case err := <-l.consul.Err():
+			if ready == nil {
+				logger.Error("lost-lock", err)
+				l.status.fail(StateLost, err)
+				return ErrLockLost
+			}
+
+			logger.Error("consul-error-without-lock", err)
+		case err := <-acquireErr:
+			l.metrics.LockAttempted(l.key, err)
+			if err != nil {
+				logger.Error("acquire-lock-failed", err, lager.Data{"retryable": IsRetryable(err)})
+				l.status.fail(StateRetrying, err)
+
+				failures++
+				if err := l.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-lock", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
+				break
+			}
+
+			logger.Info("acquire-lock-succeeded")
+			l.status.succeed(StateHolding, l.consul.ID())
+			l.metrics.LockAcquired(l.key, l.clock.Since(start))
+			close(ready)
+			ready = nil
+			c = nil
+			logger.Info("started")
+		case <-c:
+			logger.Info("retrying-acquiring-lock")
+			l.status.enter(StateRecreatingSession, l.consul.ID())
+			newSession, err := l.consul.Recreate()
+			if err != nil {
+				logger.Error("failed-to-recreate-session", err, lager.Data{"retryable": IsRetryable(err)})
+				l.status.fail(StateRetrying, err)
+
+				failures++
+				if err := l.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-lock", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = l.clock.NewTimer(retry.next()).C()
+			} else {
+				l.consul = newSession
+				l.status.enter(StateAcquiring, newSession.ID())
+				c = nil
+				go acquire(newSession)
+			}
+		}
+	}
+}
+
+func (l RWLock) writerKey() string {
+	return path.Join(l.key, "writer")
+}
+
+func (l RWLock) readersPrefix() string {
+	return path.Join(l.key, "readers") + "/"
+}
+
+func (l RWLock) acquireWrite(session *Session) error {
+	_, err := session.AcquireLock(l.writerKey(), l.value)
+	if err != nil {
+		return err
+	}
+
+	var waitIndex uint64
+	for {
+		var readers []KeyValue
+		readers, waitIndex, err = watchPrefix(l.backend, session, l.readersPrefix(), waitIndex)
+		if err != nil {
+			return err
+		}
+
+		if len(newKeySet(readers)) == 0 {
+			return nil
+		}
+	}
+}
+
+func (l RWLock) acquireRead(session *Session) error {
+	readerKey := l.readersPrefix() + session.name
+
+	for {
+		err := waitForVacantKey(l.backend, session, l.writerKey())
+		if err != nil {
+			return err
+		}
+
+		_, err = session.AcquireLock(readerKey, l.value)
+		if err != nil {
+			return err
+		}
+
+		// a writer may have arrived since we last looked; back off so that
+		// it does not wait on us
+		writer, err := l.backend.GetKey(l.writerKey())
+		if err != nil {
+			return err
+		}
+		if writer == nil || writer.Session == "" {
+			return nil
+		}
+
+		err = session.ReleaseLock(readerKey)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watchKey is watchPrefix for a single key, using a blocking WatchKey.
+func watchKey(backend Backend, session *Session, key string, waitIndex uint64) (*KeyValue, uint64, error) {
+	type getResult struct {
+		kv    *KeyValue
+		index uint64
+		err   error
+	}
+
+	changed := make(chan getResult, 1)
+	go func() {
+		kv, index, err := backend.WatchKey(key, waitIndex, defaultWatchBlockDuration)
+		changed <- getResult{kv, index, err}
+	}()
+
+	select {
+	case result := <-changed:
+		return result.kv, result.index, result.err
+	case <-session.doneCh:
+		return nil, 0, ErrInvalidSession
+	}
+}
+
+func waitForVacantKey(backend Backend, session *Session, key string) error {
+	var waitIndex uint64
+	for {
+		kv, index, err := watchKey(backend, session, key, waitIndex)
+		if err != nil {
+			return err
+		}
+		if kv == nil || kv.Session == "" {
+			return nil
+		}
+		waitIndex = index
+	}
+}
@@ -0,0 +1,108 @@
+package locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RWLock", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock     *fakeclock.FakeClock
+		backend   *locket.MemoryBackend
+		logger    *lagertest.TestLogger
+		lockKey   string
+		processes []ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		lockKey = locket.LockSchemaPath("maintenance")
+		processes = nil
+	})
+
+	AfterEach(func() {
+		for _, process := range processes {
+			ginkgomon.Kill(process)
+		}
+	})
+
+	startReader := func() ifrit.Process {
+		process := ifrit.Background(locket.NewReadLockWithBackend(logger, backend, lockKey, []byte("reader"), clock, time.Second, ttl))
+		processes = append(processes, process)
+		return process
+	}
+
+	startWriter := func() ifrit.Process {
+		process := ifrit.Background(locket.NewWriteLockWithBackend(logger, backend, lockKey, []byte("writer"), clock, time.Second, ttl))
+		processes = append(processes, process)
+		return process
+	}
+
+	It("lets readers share the lock", func() {
+		Eventually(startReader().Ready()).Should(BeClosed())
+		Eventually(startReader().Ready()).Should(BeClosed())
+	})
+
+	It("gives a writer exclusive access", func() {
+		writerA := startWriter()
+		Eventually(writerA.Ready()).Should(BeClosed())
+
+		reader := startReader()
+		writerB := startWriter()
+		Consistently(reader.Ready()).ShouldNot(BeClosed())
+		Consistently(writerB.Ready()).ShouldNot(BeClosed())
+
+		ginkgomon.Interrupt(writerA)
+		Eventually(func() bool {
+			select {
+			case <-reader.Ready():
+				return true
+			case <-writerB.Ready():
+				return true
+			default:
+				return false
+			}
+		}).Should(BeTrue())
+	})
+
+	It("keeps new readers out while a writer waits", func() {
+		readerA := startReader()
+		Eventually(readerA.Ready()).Should(BeClosed())
+
+		writer := startWriter()
+		Consistently(writer.Ready()).ShouldNot(BeClosed())
+
+		readerB := startReader()
+		Consistently(readerB.Ready()).ShouldNot(BeClosed())
+
+		ginkgomon.Interrupt(readerA)
+		Eventually(writer.Ready()).Should(BeClosed())
+		Consistently(readerB.Ready()).ShouldNot(BeClosed())
+
+		ginkgomon.Interrupt(writer)
+		Eventually(readerB.Ready()).Should(BeClosed())
+	})
+
+	It("exits when the lock is lost", func() {
+		writer := startWriter()
+		Eventually(writer.Ready()).Should(BeClosed())
+
+		holder, err := locket.FetchLockWithBackend(backend, lockKey+"/writer")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backend.DestroySession(holder.SessionID)).To(Succeed())
+
+		Eventually(writer.Wait()).Should(Receive(Equal(locket.ErrLockLost)))
+	})
+})
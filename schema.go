@@ -9,7 +9,17 @@ const LockTTL = 10 * time.Second
 const RetryInterval = 5 * time.Second
 
 const LockSchemaRoot = "v1/locks"
+const PresenceSchemaRoot = "v1/presence"
+
+const (
+	LockType     = "lock"
+	PresenceType = "presence"
+)
 
 func LockSchemaPath(lockName ...string) string {
 	return path.Join(LockSchemaRoot, path.Join(lockName...))
 }
+
+func PresenceSchemaPath(presenceName ...string) string {
+	return path.Join(PresenceSchemaRoot, path.Join(presenceName...))
+}
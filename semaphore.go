@@ -0,0 +1,254 @@
+package locket
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"code.cloudfoundry.org/lager"
+	"github.com/nu7hatch/gouuid"
+)
+
+var (
+	ErrSemaphoreSlotLost = errors.New("semaphore slot lost")
+	ErrInvalidLimit      = errors.New("semaphore limit must be positive")
+)
+
+// Semaphore holds one of limit slots under a key prefix. Each slot is a lock
+// key bound to the runner's session, so a slot frees itself when the holder's
+// session goes away.
+type Semaphore struct {
+	backend Backend
+	consul  *Session
+	prefix  string
+	limit   int
+	value   []byte
+
+	clock         clock.Clock
+	retryInterval time.Duration
+	options       runnerOptions
+	metrics       MetricsEmitter
+
+	logger lager.Logger
+	status *statusTracker
+}
+
+func NewSemaphore(
+	logger lager.Logger,
+	consulClient consuladapter.Client,
+	keyPrefix string,
+	limit int,
+	value []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	ttl time.Duration,
+	opts ...Option,
+) Semaphore {
+	return NewSemaphoreWithBackend(logger, NewConsulBackend(consulClient), keyPrefix, limit, value, clock, retryInterval, ttl, opts...)
+}
+
+func NewSemaphoreWithBackend(
+	logger lager.Logger,
+	backend Backend,
+	keyPrefix string,
+	limit int,
+	value []byte,
+	clock clock.Clock,
+	retryInterval time.Duration,
+	ttl time.Duration,
+	opts ...Option,
+) Semaphore {
+	if limit <= 0 {
+		logger.Fatal("invalid-semaphore-limit", ErrInvalidLimit, lager.Data{"limit": limit})
+	}
+
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		logger.Fatal("create-uuid-failed", err)
+	}
+
+	session, err := NewSessionNoChecksWithBackend(uuid.String(), ttl, backend)
+	if err != nil {
+		logger.Fatal("consul-session-failed", err)
+	}
+
+	options := newRunnerOptions(opts)
+
+	return Semaphore{
+		backend: backend,
+		consul:  session,
+		prefix:  keyPrefix,
+		limit:   limit,
+		value:   value,
+
+		clock:         clock,
+		retryInterval: retryInterval,
+		options:       options,
+		metrics:       options.metricsOr(NoopMetricsEmitter{}),
+
+		logger: logger,
+		status: newStatusTracker(clock),
+	}
+}
+
+// Status returns what the semaphore is doing. It is safe to call from any
+// goroutine.
+func (s Semaphore) Status() Status {
+	return s.status.get()
+}
+
+// StatusChanges receives the status every time it changes. Only the latest
+// change is kept for a receiver that falls behind.
+func (s Semaphore) StatusChanges() <-chan Status {
+	return s.status.changes
+}
+
+func (s Semaphore) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := s.logger.Session("semaphore", lager.Data{"prefix": s.prefix, "limit": s.limit})
+	logger.Info("starting")
+
+	defer func() {
+		s.consul.Destroy()
+		logger.Info("done")
+	}()
+
+	defer activity.addRunner("semaphore", s.prefix, s.status)()
+	defer s.status.enter(StateStopped, "")
+
+	type acquireResult struct {
+		slot string
+		err  error
+	}
+	acquired := make(chan acquireResult, 1)
+
+	acquire := func(session *Session) {
+		logger.Info("acquiring-slot")
+		slot, err := s.acquireSlot(session)
+		acquired <- acquireResult{slot: slot, err: err}
+	}
+
+	var c <-chan time.Time
+	var failures int
+	retry := newRetrier(s.options.backoffOr(s.retryInterval))
+
+	start := s.clock.Now()
+	s.status.enter(StateAcquiring, "")
+	go acquire(s.consul)
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("shutting-down", lager.Data{"received-signal": sig})
+			return nil
+		case err := <-s.consul.Err():
+			if ready == nil {
+				logger.Error("lost-slot", err)
+				s.status.fail(StateLost, err)
+				return ErrSemaphoreSlotLost
+			}
+
+			logger.Error("consul-error-without-slot", err)
+		case result := <-acquired:
+			s.metrics.LockAttempted(s.prefix, result.err)
+			if result.err != nil {
+				logger.Error("acquire-slot-failed", result.err, lager.Data{"retryable": IsRetryable(result.err)})
+				s.status.fail(StateRetrying, result.err)
+
+				failures++
+				if err := s.options.retryPolicy.check(result.err, failures); err != nil {
+					logger.Error("giving-up-acquiring-slot", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = s.clock.NewTimer(retry.next()).C()
+				break
+			}
+
+			logger.Info("acquire-slot-succeeded", lager.Data{"slot": result.slot})
+			s.status.succeed(StateHolding, s.consul.ID())
+			s.metrics.LockAcquired(s.prefix, s.clock.Since(start))
+			close(ready)
+			ready = nil
+			logger.Info("started")
+		case <-c:
+			logger.Info("retrying-acquiring-slot")
+			s.status.enter(StateRecreatingSession, s.consul.ID())
+			newSession, err := s.consul.Recreate()
+			if err != nil {
+				logger.Error("failed-to-recreate-session", err, lager.Data{"retryable": IsRetryable(err)})
+				s.status.fail(StateRetrying, err)
+
+				failures++
+				if err := s.options.retryPolicy.check(err, failures); err != nil {
+					logger.Error("giving-up-acquiring-slot", err, lager.Data{"failures": failures})
+					return err
+				}
+
+				c = s.clock.NewTimer(retry.next()).C()
+			} else {
+				s.consul = newSession
+				s.status.enter(StateAcquiring, newSession.ID())
+				c = nil
+				go acquire(newSession)
+			}
+		}
+	}
+}
+
+// acquireSlot tries every slot in turn, and waits for the prefix to change
+// whenever all of them are taken.
+func (s Semaphore) acquireSlot(session *Session) (string, error) {
+	var waitIndex uint64
+
+	for {
+		for i := 0; i < s.limit; i++ {
+			slot := path.Join(s.prefix, strconv.Itoa(i))
+			_, ok, err := session.TryAcquireLock(slot, s.value)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return slot, nil
+			}
+		}
+
+		var err error
+		_, waitIndex, err = watchPrefix(s.backend, session, s.slotsPrefix(), waitIndex)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// slotsPrefix ends in a slash, so that watching the slots neither matches
+// sibling prefixes nor creates a key at the prefix itself.
+func (s Semaphore) slotsPrefix() string {
+	return path.Clean(s.prefix) + "/"
+}
+
+// watchPrefix is a blocking ListPrefix that gives up with ErrInvalidSession
+// once the session is done.
+func watchPrefix(backend Backend, session *Session, prefix string, waitIndex uint64) ([]KeyValue, uint64, error) {
+	type listResult struct {
+		kvs   []KeyValue
+		index uint64
+		err   error
+	}
+
+	changed := make(chan listResult, 1)
+	go func() {
+		kvs, index, err := backend.ListPrefix(prefix, waitIndex, defaultWatchBlockDuration)
+		changed <- listResult{kvs, index, err}
+	}()
+
+	select {
+	case result := <-changed:
+		return result.kvs, result.index, result.err
+	case <-session.doneCh:
+		return nil, 0, ErrInvalidSession
+	}
+}
@@ -0,0 +1,119 @@
+package locket_test
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Semaphore", func() {
+	const ttl = 10 * time.Second
+
+	var (
+		clock     *fakeclock.FakeClock
+		backend   *locket.MemoryBackend
+		logger    *lagertest.TestLogger
+		prefix    string
+		processes []ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+		prefix = locket.LockSchemaPath("migration")
+		processes = nil
+	})
+
+	AfterEach(func() {
+		for _, process := range processes {
+			ginkgomon.Kill(process)
+		}
+	})
+
+	start := func(value string) ifrit.Process {
+		semaphore := locket.NewSemaphoreWithBackend(logger, backend, prefix, 2, []byte(value), clock, time.Second, ttl)
+		process := ifrit.Background(semaphore)
+		processes = append(processes, process)
+		return process
+	}
+
+	It("lets up to limit holders in at once", func() {
+		a := start("a")
+		b := start("b")
+		Eventually(a.Ready()).Should(BeClosed())
+		Eventually(b.Ready()).Should(BeClosed())
+
+		c := start("c")
+		Consistently(c.Ready()).ShouldNot(BeClosed())
+
+		ginkgomon.Interrupt(a)
+		Eventually(c.Ready()).Should(BeClosed())
+
+		kvs, _, err := backend.ListPrefix(prefix, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		var values []string
+		for _, kv := range kvs {
+			values = append(values, string(kv.Value))
+		}
+		Expect(values).To(ConsistOf("b", "c"))
+	})
+
+	It("only watches the keys under its prefix", func() {
+		recording := &prefixRecordingBackend{Backend: backend}
+		semaphore := locket.NewSemaphoreWithBackend(logger, recording, prefix, 1, []byte("a"), clock, time.Second, ttl)
+		a := start("a")
+		Eventually(a.Ready()).Should(BeClosed())
+
+		b := ifrit.Background(semaphore)
+		processes = append(processes, b)
+		Eventually(recording.prefixes).Should(ContainElement(prefix + "/"))
+		Expect(recording.prefixes()).To(HaveEach(prefix + "/"))
+	})
+
+	It("refuses a limit below one", func() {
+		Expect(func() {
+			locket.NewSemaphoreWithBackend(logger, backend, prefix, 0, []byte("a"), clock, time.Second, ttl)
+		}).To(Panic())
+	})
+
+	It("exits when its slot is lost", func() {
+		a := start("a")
+		Eventually(a.Ready()).Should(BeClosed())
+
+		kvs, _, err := backend.ListPrefix(prefix, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvs).To(HaveLen(1))
+		Expect(backend.DestroySession(kvs[0].Session)).To(Succeed())
+
+		Eventually(a.Wait()).Should(Receive(Equal(locket.ErrSemaphoreSlotLost)))
+	})
+})
+
+type prefixRecordingBackend struct {
+	locket.Backend
+
+	lock   sync.Mutex
+	listed []string
+}
+
+func (b *prefixRecordingBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]locket.KeyValue, uint64, error) {
+	b.lock.Lock()
+	b.listed = append(b.listed, prefix)
+	b.lock.Unlock()
+	return b.Backend.ListPrefix(prefix, waitIndex, waitTime)
+}
+
+func (b *prefixRecordingBackend) prefixes() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string(nil), b.listed...)
+}
@@ -3,12 +3,11 @@ package locket
 import (
 	"errors"
 	"fmt"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
 	"code.cloudfoundry.org/consuladapter"
-	"github.com/hashicorp/consul/api"
 )
 
 type LostLockError string
@@ -17,12 +16,10 @@ func (e LostLockError) Error() string {
 	return fmt.Sprintf("Lost lock '%s'", string(e))
 }
 
-var ErrInvalidSession = errors.New("invalid session")
 var ErrDestroyed = errors.New("already destroyed")
-var ErrCancelled = errors.New("cancelled")
 
 type Session struct {
-	client consuladapter.Client
+	backend Backend
 
 	name     string
 	ttl      time.Duration
@@ -35,27 +32,42 @@ type Session struct {
 	destroyed bool
 	doneCh    chan struct{}
 	lostLock  string
+	held      map[string]<-chan struct{}
+	released  map[<-chan struct{}]struct{}
+	present   map[string]struct{}
+	renewedAt time.Time
 }
 
 func NewSession(sessionName string, ttl time.Duration, client consuladapter.Client) (*Session, error) {
-	return newSession(sessionName, ttl, false, client)
+	return newSession(sessionName, ttl, false, NewConsulBackend(client))
 }
 
 func NewSessionNoChecks(sessionName string, ttl time.Duration, client consuladapter.Client) (*Session, error) {
-	return newSession(sessionName, ttl, true, client)
+	return newSession(sessionName, ttl, true, NewConsulBackend(client))
 }
 
-func newSession(sessionName string, ttl time.Duration, noChecks bool, client consuladapter.Client) (*Session, error) {
+func NewSessionWithBackend(sessionName string, ttl time.Duration, backend Backend) (*Session, error) {
+	return newSession(sessionName, ttl, false, backend)
+}
+
+func NewSessionNoChecksWithBackend(sessionName string, ttl time.Duration, backend Backend) (*Session, error) {
+	return newSession(sessionName, ttl, true, backend)
+}
+
+func newSession(sessionName string, ttl time.Duration, noChecks bool, backend Backend) (*Session, error) {
 	doneCh := make(chan struct{}, 1)
 	errCh := make(chan error, 1)
 
 	s := &Session{
-		client:   client,
+		backend:  backend,
 		name:     sessionName,
 		ttl:      ttl,
 		noChecks: noChecks,
 		doneCh:   doneCh,
 		errCh:    errCh,
+		held:     map[string]<-chan struct{}{},
+		released: map[<-chan struct{}]struct{}{},
+		present:  map[string]struct{}{},
 	}
 
 	return s, nil
@@ -83,10 +95,11 @@ func (s *Session) destroy() {
 		close(s.doneCh)
 
 		if s.id != "" {
-			s.client.Session().Destroy(s.id, nil)
+			s.backend.DestroySession(s.id)
 		}
 
 		s.destroyed = true
+		activity.removeSession(s)
 	}
 }
 
@@ -100,22 +113,16 @@ func (s *Session) createSession() error {
 		return nil
 	}
 
-	se := &api.SessionEntry{
-		Name:      s.name,
-		Behavior:  api.SessionBehaviorDelete,
-		TTL:       s.ttl.String(),
-		LockDelay: 1 * time.Nanosecond,
-	}
-
-	id, renewTTL, err := create(se, s.noChecks, s.client)
+	id, err := s.backend.CreateSession(s.name, s.ttl, s.noChecks)
 	if err != nil {
 		return err
 	}
 
 	s.id = id
+	activity.addSession(s)
 
 	go func() {
-		err := s.client.Session().RenewPeriodic(renewTTL, id, nil, s.doneCh)
+		err := s.backend.RenewSession(id, s.ttl, s.doneCh, s.renewed)
 		s.lock.Lock()
 		lostLock := s.lostLock
 		s.destroy()
@@ -123,8 +130,6 @@ func (s *Session) createSession() error {
 
 		if lostLock != "" {
 			err = LostLockError(lostLock)
-		} else {
-			err = convertError(err)
 		}
 		s.errCh <- err
 	}()
@@ -132,11 +137,47 @@ func (s *Session) createSession() error {
 	return err
 }
 
+func (s *Session) renewed(at time.Time) {
+	s.lock.Lock()
+	s.renewedAt = at
+	s.lock.Unlock()
+}
+
+// info describes the session for the debug handler.
+func (s *Session) info() DebugSession {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keys := []string{}
+	for key := range s.held {
+		keys = append(keys, key)
+	}
+	for key := range s.present {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return DebugSession{
+		ID:          s.id,
+		Name:        s.name,
+		TTL:         s.ttl.String(),
+		Keys:        keys,
+		LastRenewal: s.renewedAt,
+	}
+}
+
+// clone returns a session with the same settings that is created on first
+// use.
+func (s *Session) clone() *Session {
+	session, _ := newSession(s.name, s.ttl, s.noChecks, s.backend)
+	return session
+}
+
 func (s *Session) Recreate() (*Session, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	session, err := newSession(s.name, s.ttl, s.noChecks, s.client)
+	session, err := newSession(s.name, s.ttl, s.noChecks, s.backend)
 	if err != nil {
 		return nil, err
 	}
@@ -149,41 +190,101 @@ func (s *Session) Recreate() (*Session, error) {
 	return session, err
 }
 
-func (s *Session) AcquireLock(key string, value []byte) error {
+// AcquireLock blocks until the session holds the key. It returns a fencing
+// token that is greater than the one returned to any earlier holder of the
+// key, or ErrInvalidSession if the session ends first.
+func (s *Session) AcquireLock(key string, value []byte) (uint64, error) {
+	token, acquired, err := s.acquireLock(key, value, false)
+	if err != nil {
+		return 0, err
+	}
+	if !acquired {
+		return 0, ErrInvalidSession
+	}
+
+	return token, nil
+}
+
+// TryAcquireLock is AcquireLock without waiting: it returns false when the
+// key is held by another session.
+func (s *Session) TryAcquireLock(key string, value []byte) (uint64, bool, error) {
+	return s.acquireLock(key, value, true)
+}
+
+func (s *Session) acquireLock(key string, value []byte, tryOnce bool) (uint64, bool, error) {
 	s.lock.Lock()
 	err := s.createSession()
 	s.lock.Unlock()
 	if err != nil {
-		return err
+		return 0, false, err
 	}
 
-	lockOptions := api.LockOptions{
-		Key:              key,
-		Value:            value,
-		Session:          s.id,
-		MonitorRetries:   7,
-		MonitorRetryTime: 2 * time.Second,
+	var lostCh <-chan struct{}
+	var token uint64
+	if tryOnce {
+		lostCh, token, err = s.backend.TryAcquireKey(s.id, key, value, LockType)
+	} else {
+		lostCh, token, err = s.backend.AcquireKey(s.id, key, value, LockType, s.doneCh)
 	}
-
-	lock, err := s.client.LockOpts(&lockOptions)
 	if err != nil {
-		return convertError(err)
+		return 0, false, err
+	}
+	if lostCh == nil {
+		return 0, false, nil
 	}
 
-	lostCh, err := lock.Lock(s.doneCh)
+	s.hold(key, lostCh)
+	return token, true, nil
+}
+
+// TryAcquireLocks is TryAcquireLock for a set of keys: the session either
+// holds all of them, or none of them and it returns one that is held by
+// another session.
+func (s *Session) TryAcquireLocks(keys []string, value []byte) (string, error) {
+	s.lock.Lock()
+	err := s.createSession()
+	s.lock.Unlock()
 	if err != nil {
-		return convertError(err)
+		return "", err
 	}
-	if lostCh == nil {
-		return ErrCancelled
+
+	kvs := make([]KeyValue, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, KeyValue{Key: key, Value: value, Type: LockType})
+	}
+
+	lostChs, contended, err := s.backend.AcquireKeys(s.id, kvs)
+	if err != nil || contended != "" {
+		return contended, err
 	}
 
+	for i, key := range keys {
+		s.hold(key, lostChs[i])
+	}
+	return "", nil
+}
+
+// hold records a key acquired by the session, which is destroyed if the key
+// is lost before it is released.
+func (s *Session) hold(key string, lostCh <-chan struct{}) {
+	s.lock.Lock()
+	s.held[key] = lostCh
+	s.lock.Unlock()
+
 	go func() {
 		select {
 		case <-lostCh:
 			s.lock.Lock()
 			defer s.lock.Unlock()
 
+			if _, ok := s.released[lostCh]; ok {
+				delete(s.released, lostCh)
+				return
+			}
+			if s.held[key] == lostCh {
+				delete(s.held, key)
+			}
+
 			if s.destroyed {
 				s.errCh <- LostLockError(key)
 				return
@@ -194,8 +295,23 @@ func (s *Session) AcquireLock(key string, value []byte) error {
 		case <-s.doneCh:
 		}
 	}()
+}
 
-	return nil
+// ReleaseLock gives up a key acquired with AcquireLock or TryAcquireLock
+// without destroying the session.
+func (s *Session) ReleaseLock(key string) error {
+	s.lock.Lock()
+	lostCh, ok := s.held[key]
+	if !ok {
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.held, key)
+	s.released[lostCh] = struct{}{}
+	id := s.id
+	s.lock.Unlock()
+
+	return s.backend.ReleaseKey(id, key)
 }
 
 func (s *Session) SetPresence(key string, value []byte) (<-chan string, error) {
@@ -206,31 +322,25 @@ func (s *Session) SetPresence(key string, value []byte) (<-chan string, error) {
 		return nil, err
 	}
 
-	lockOptions := api.LockOptions{
-		Key:              key,
-		Value:            value,
-		Session:          s.id,
-		MonitorRetries:   7,
-		MonitorRetryTime: 2 * time.Second,
-	}
-
-	lock, err := s.client.LockOpts(&lockOptions)
-	if err != nil {
-		return nil, convertError(err)
-	}
-
-	lostCh, err := lock.Lock(s.doneCh)
+	lostCh, _, err := s.backend.AcquireKey(s.id, key, value, PresenceType, s.doneCh)
 	if err != nil {
-		return nil, convertError(err)
+		return nil, err
 	}
 	if lostCh == nil {
-		return nil, ErrCancelled
+		return nil, ErrInvalidSession
 	}
 
+	s.lock.Lock()
+	s.present[key] = struct{}{}
+	s.lock.Unlock()
+
 	presenceLost := make(chan string, 1)
 	go func() {
 		select {
 		case <-lostCh:
+			s.lock.Lock()
+			delete(s.present, key)
+			s.lock.Unlock()
 			presenceLost <- key
 		case <-s.doneCh:
 		}
@@ -238,65 +348,3 @@ func (s *Session) SetPresence(key string, value []byte) (<-chan string, error) {
 
 	return presenceLost, nil
 }
-
-func create(se *api.SessionEntry, noChecks bool, client consuladapter.Client) (string, string, error) {
-	session := client.Session()
-	agent := client.Agent()
-
-	nodeName, err := agent.NodeName()
-	if err != nil {
-		return "", "", err
-	}
-
-	nodeSessions, _, err := session.Node(nodeName, nil)
-	if err != nil {
-		return "", "", err
-	}
-
-	sessions := findSessions(se.Name, nodeSessions)
-	if sessions != nil {
-		for _, s := range sessions {
-			_, err = session.Destroy(s.ID, nil)
-			if err != nil {
-				return "", "", err
-			}
-		}
-	}
-
-	var f func(*api.SessionEntry, *api.WriteOptions) (string, *api.WriteMeta, error)
-	if noChecks {
-		f = session.CreateNoChecks
-	} else {
-		f = session.Create
-	}
-
-	id, _, err := f(se, nil)
-	if err != nil {
-		return "", "", err
-	}
-
-	return id, se.TTL, nil
-}
-
-func findSessions(name string, sessions []*api.SessionEntry) []*api.SessionEntry {
-	var matches []*api.SessionEntry
-	for _, session := range sessions {
-		if session.Name == name {
-			matches = append(matches, session)
-		}
-	}
-
-	return matches
-}
-
-func convertError(err error) error {
-	if err == nil {
-		return err
-	}
-
-	if strings.Contains(err.Error(), "500 (Invalid session)") {
-		return ErrInvalidSession
-	}
-
-	return err
-}
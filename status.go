@@ -0,0 +1,110 @@
+package locket
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+type RunnerState string
+
+const (
+	StateStarting          RunnerState = "starting"
+	StateAcquiring         RunnerState = "acquiring"
+	StateHolding           RunnerState = "holding"
+	StateRetrying          RunnerState = "retrying"
+	StateRecreatingSession RunnerState = "recreating-session"
+	StateWatching          RunnerState = "watching"
+	StateRegistered        RunnerState = "registered"
+	StateLost              RunnerState = "lost"
+	StateStopped           RunnerState = "stopped"
+)
+
+// Status is what a runner is doing. Since is when it entered State, and
+// ConsecutiveFailures and LastError describe the failures since it last
+// succeeded.
+type Status struct {
+	State               RunnerState
+	SessionID           string
+	Since               time.Time
+	ConsecutiveFailures int
+	LastError           error
+}
+
+// statusTracker is shared by the copies of a runner. A nil tracker ignores
+// updates, for the functions that run without a runner.
+type statusTracker struct {
+	clock clock.Clock
+
+	lock    sync.Mutex
+	status  Status
+	changes chan Status
+}
+
+func newStatusTracker(clock clock.Clock) *statusTracker {
+	return &statusTracker{
+		clock:   clock,
+		status:  Status{State: StateStarting, Since: clock.Now()},
+		changes: make(chan Status, 1),
+	}
+}
+
+func (t *statusTracker) get() Status {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.status
+}
+
+// enter moves to state, keeping the session ID and the failures.
+func (t *statusTracker) enter(state RunnerState, sessionID string) {
+	t.update(func(s *Status) {
+		s.State = state
+		s.SessionID = sessionID
+	})
+}
+
+// succeed moves to state and clears the failures.
+func (t *statusTracker) succeed(state RunnerState, sessionID string) {
+	t.update(func(s *Status) {
+		s.State = state
+		s.SessionID = sessionID
+		s.ConsecutiveFailures = 0
+		s.LastError = nil
+	})
+}
+
+// fail moves to state and records err as another failure.
+func (t *statusTracker) fail(state RunnerState, err error) {
+	t.update(func(s *Status) {
+		s.State = state
+		s.ConsecutiveFailures++
+		s.LastError = err
+	})
+}
+
+func (t *statusTracker) update(change func(*Status)) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	previous := t.status
+	change(&t.status)
+	if t.status.State != previous.State {
+		t.status.Since = t.clock.Now()
+	} else if t.status.SessionID == previous.SessionID &&
+		t.status.ConsecutiveFailures == previous.ConsecutiveFailures &&
+		t.status.LastError == nil && previous.LastError == nil {
+		return
+	}
+
+	// keep only the latest change for a receiver that falls behind
+	select {
+	case <-t.changes:
+	default:
+	}
+	t.changes <- t.status
+}
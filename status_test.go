@@ -0,0 +1,174 @@
+package locket_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	const ttl = time.Minute
+
+	var (
+		clock   *fakeclock.FakeClock
+		backend *locket.MemoryBackend
+		logger  *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewMemoryBackend(clock)
+		logger = lagertest.NewTestLogger("locket")
+	})
+
+	stop := func(process ifrit.Process) {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	}
+
+	Describe("Lock", func() {
+		It("reports holding the lock with its session", func() {
+			lock := locket.NewLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl)
+			Expect(lock.Status().State).To(Equal(locket.StateStarting))
+
+			process := ifrit.Invoke(lock)
+			status := lock.Status()
+			Expect(status.State).To(Equal(locket.StateHolding))
+			Expect(status.Since).To(Equal(clock.Now()))
+
+			kv, err := backend.GetKey("some-key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.SessionID).To(Equal(kv.Session))
+
+			stop(process)
+			Expect(lock.Status().State).To(Equal(locket.StateStopped))
+		})
+
+		It("reports failures while retrying", func() {
+			failing := failingBackend{Backend: backend, err: errors.New("boom")}
+			lock := locket.NewLockWithBackend(logger, failing, "some-key", []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Background(lock)
+			defer stop(process)
+
+			var status locket.Status
+			Eventually(lock.StatusChanges()).Should(Receive(&status, HaveField("State", locket.StateRetrying)))
+			Expect(status.ConsecutiveFailures).To(Equal(1))
+			Expect(status.LastError).To(Equal(failing.err))
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(lock.Status).Should(HaveField("ConsecutiveFailures", 2))
+		})
+	})
+
+	Describe("Presence", func() {
+		It("reports holding the presence", func() {
+			presence := locket.NewPresenceWithBackend(logger, backend, "some-presence", []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Invoke(presence)
+
+			Eventually(presence.Status).Should(HaveField("State", locket.StateHolding))
+			Expect(presence.Status().SessionID).NotTo(BeEmpty())
+
+			stop(process)
+			Expect(presence.Status().State).To(Equal(locket.StateStopped))
+		})
+	})
+
+	Describe("Semaphore", func() {
+		It("reports holding a slot with its session", func() {
+			semaphore := locket.NewSemaphoreWithBackend(logger, backend, "some-prefix", 1, []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Invoke(semaphore)
+
+			status := semaphore.Status()
+			Expect(status.State).To(Equal(locket.StateHolding))
+
+			kv, err := backend.GetKey("some-prefix/0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.SessionID).To(Equal(kv.Session))
+
+			stop(process)
+			Expect(semaphore.Status().State).To(Equal(locket.StateStopped))
+		})
+
+		It("reports failures while retrying", func() {
+			failing := failingBackend{Backend: backend, err: errors.New("boom")}
+			semaphore := locket.NewSemaphoreWithBackend(logger, failing, "some-prefix", 1, []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Background(semaphore)
+			defer stop(process)
+
+			Eventually(semaphore.Status).Should(HaveField("State", locket.StateRetrying))
+			Expect(semaphore.Status().LastError).To(Equal(failing.err))
+		})
+	})
+
+	Describe("RWLock", func() {
+		It("reports holding the write lock", func() {
+			lock := locket.NewWriteLockWithBackend(logger, backend, "some-key", []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Invoke(lock)
+
+			Expect(lock.Status().State).To(Equal(locket.StateHolding))
+			Expect(lock.Status().SessionID).NotTo(BeEmpty())
+
+			stop(process)
+			Expect(lock.Status().State).To(Equal(locket.StateStopped))
+		})
+	})
+
+	Describe("MultiLock", func() {
+		It("reports holding the locks", func() {
+			lock := locket.NewMultiLockWithBackend(logger, backend, []string{"a", "b"}, []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Invoke(lock)
+
+			Expect(lock.Status().State).To(Equal(locket.StateHolding))
+
+			stop(process)
+			Expect(lock.Status().State).To(Equal(locket.StateStopped))
+		})
+
+		It("reports failures while retrying", func() {
+			failing := failingBackend{Backend: backend, err: errors.New("boom")}
+			lock := locket.NewMultiLockWithBackend(logger, failing, []string{"a", "b"}, []byte("a"), clock, time.Second, ttl)
+			process := ifrit.Background(lock)
+			defer stop(process)
+
+			Eventually(lock.Status).Should(HaveField("State", locket.StateRetrying))
+			Expect(lock.Status().ConsecutiveFailures).To(Equal(1))
+		})
+	})
+
+	Describe("DisappearanceWatcher", func() {
+		It("reports watching and failed watches", func() {
+			failing := &failingListBackend{Backend: backend, err: errors.New("boom")}
+			watcher, _ := locket.NewDisappearanceWatcherWithBackend(logger, failing, "under", clock)
+			process := ifrit.Invoke(watcher)
+			defer stop(process)
+
+			Eventually(watcher.Status).Should(HaveField("State", locket.StateRetrying))
+			Expect(watcher.Status().LastError).To(Equal(failing.err))
+
+			clock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(watcher.Status).Should(HaveField("State", locket.StateWatching))
+			Expect(watcher.Status().ConsecutiveFailures).To(BeZero())
+		})
+	})
+
+	Describe("LeaderWatcher", func() {
+		It("reports watching", func() {
+			watcher, events := locket.NewLeaderWatcherWithBackend(logger, backend, "some-key", clock)
+			process := ifrit.Invoke(watcher)
+
+			Eventually(events).Should(Receive())
+			Expect(watcher.Status().State).To(Equal(locket.StateWatching))
+
+			stop(process)
+			Expect(watcher.Status().State).To(Equal(locket.StateStopped))
+		})
+	})
+})
@@ -0,0 +1,523 @@
+package locket
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/nu7hatch/gouuid"
+)
+
+var (
+	ErrLockCollision    = errors.New("lock collision")
+	ErrResourceNotFound = errors.New("resource not found")
+)
+
+const DefaultStorePollInterval = 1 * time.Second
+
+// Resource is a lock or presence record kept in a LockStore.
+type Resource struct {
+	Key   string
+	Owner string
+	Value []byte
+	Type  string
+}
+
+//go:generate counterfeiter -o fakes/fake_lock_store.go . LockStore
+
+// LockStore keeps locks and presences as records with a TTL instead of as
+// session-bound keys. Lock acquires a resource, or refreshes it when it is
+// already held by the same owner, and fails with ErrLockCollision when it is
+// held by someone else. The index it returns increases with every write to
+// the store. LockAll locks every resource or, failing with ErrLockCollision
+// when any of them is held by someone else, none of them. FetchAll returns
+// every type when lockType is empty.
+type LockStore interface {
+	Lock(resource Resource, ttl time.Duration) (int64, error)
+	LockAll(resources []Resource, ttl time.Duration) ([]int64, error)
+	Release(resource Resource) error
+	Fetch(key string) (*Resource, error)
+	FetchAll(lockType string) ([]Resource, error)
+}
+
+type storeBackend struct {
+	store        LockStore
+	clock        clock.Clock
+	pollInterval time.Duration
+
+	lock     sync.Mutex
+	sessions map[string]*storeSession
+}
+
+type storeSession struct {
+	name   string
+	ttl    time.Duration
+	keys   map[string]*storeKey
+	doneCh chan struct{}
+}
+
+type storeKey struct {
+	resource Resource
+	lostCh   chan struct{}
+}
+
+// NewLockStoreBackend adapts a LockStore to a Backend. Sessions only exist in
+// this process: they own the records they acquire and renew them every half
+// TTL, and the store expires the records of sessions that stop renewing. Keys
+// without an owner cannot be written, so ForceRelease returns
+// ErrForceReleaseUnsupported.
+func NewLockStoreBackend(store LockStore, clock clock.Clock, pollInterval time.Duration) Backend {
+	return &storeBackend{
+		store:        store,
+		clock:        clock,
+		pollInterval: pollInterval,
+		sessions:     map[string]*storeSession{},
+	}
+}
+
+func (b *storeBackend) CreateSession(name string, ttl time.Duration, noChecks bool) (string, error) {
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	id := uuid.String()
+
+	var released []Resource
+
+	b.lock.Lock()
+	for existingID, s := range b.sessions {
+		if s.name == name {
+			released = append(released, b.invalidate(existingID)...)
+		}
+	}
+	b.sessions[id] = &storeSession{
+		name:   name,
+		ttl:    ttl,
+		keys:   map[string]*storeKey{},
+		doneCh: make(chan struct{}),
+	}
+	b.lock.Unlock()
+
+	return id, b.release(released)
+}
+
+func (b *storeBackend) RenewSession(id string, ttl time.Duration, doneCh chan struct{}, renewed func(time.Time)) error {
+	b.lock.Lock()
+	s, ok := b.sessions[id]
+	b.lock.Unlock()
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	timer := b.clock.NewTimer(ttl / 2)
+	defer timer.Stop()
+
+	lastRenewed := b.clock.Now()
+	if renewed != nil {
+		renewed(lastRenewed)
+	}
+
+	for {
+		select {
+		case <-doneCh:
+			return b.DestroySession(id)
+		case <-s.doneCh:
+			return ErrInvalidSession
+		case <-timer.C():
+			err := b.renew(id, s)
+			if err == nil {
+				lastRenewed = b.clock.Now()
+				if renewed != nil {
+					renewed(lastRenewed)
+				}
+			} else if b.clock.Since(lastRenewed) >= s.ttl {
+				b.DestroySession(id)
+				return err
+			}
+
+			timer.Reset(ttl / 2)
+		}
+	}
+}
+
+func (b *storeBackend) DestroySession(id string) error {
+	b.lock.Lock()
+	released := b.invalidate(id)
+	b.lock.Unlock()
+
+	return b.release(released)
+}
+
+// SessionInfo only knows the names of sessions created by this process. The
+// sessions of other processes are reported by ID alone.
+func (b *storeBackend) SessionInfo(id string) (*SessionInfo, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	info := &SessionInfo{ID: id}
+	if s, ok := b.sessions[id]; ok {
+		info.Name = s.name
+	}
+
+	return info, nil
+}
+
+func (b *storeBackend) AcquireKey(sessionID, key string, value []byte, lockType string, stopCh <-chan struct{}) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	s, ok := b.sessions[sessionID]
+	b.lock.Unlock()
+	if !ok {
+		return nil, 0, ErrInvalidSession
+	}
+
+	resource := Resource{Key: key, Owner: sessionID, Value: value, Type: lockType}
+
+	for {
+		lostCh, index, err := b.tryAcquire(s, resource)
+		if err != nil || lostCh != nil {
+			return lostCh, index, err
+		}
+
+		timer := b.clock.NewTimer(b.pollInterval)
+		select {
+		case <-timer.C():
+		case <-stopCh:
+			timer.Stop()
+			return nil, 0, nil
+		case <-s.doneCh:
+			timer.Stop()
+			return nil, 0, ErrInvalidSession
+		}
+	}
+}
+
+func (b *storeBackend) TryAcquireKey(sessionID, key string, value []byte, lockType string) (<-chan struct{}, uint64, error) {
+	b.lock.Lock()
+	s, ok := b.sessions[sessionID]
+	b.lock.Unlock()
+	if !ok {
+		return nil, 0, ErrInvalidSession
+	}
+
+	return b.tryAcquire(s, Resource{Key: key, Owner: sessionID, Value: value, Type: lockType})
+}
+
+func (b *storeBackend) tryAcquire(s *storeSession, resource Resource) (<-chan struct{}, uint64, error) {
+	index, err := b.store.Lock(resource, s.ttl)
+	if err == ErrLockCollision {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lostChs, err := b.hold(s, []Resource{resource})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lostChs[0], uint64(index), nil
+}
+
+func (b *storeBackend) AcquireKeys(sessionID string, kvs []KeyValue) ([]<-chan struct{}, string, error) {
+	b.lock.Lock()
+	s, ok := b.sessions[sessionID]
+	b.lock.Unlock()
+	if !ok {
+		return nil, "", ErrInvalidSession
+	}
+
+	resources := make([]Resource, 0, len(kvs))
+	for _, kv := range kvs {
+		resources = append(resources, Resource{Key: kv.Key, Owner: sessionID, Value: kv.Value, Type: kv.Type})
+	}
+
+	_, err := b.store.LockAll(resources, s.ttl)
+	if err == ErrLockCollision {
+		return nil, b.contended(resources), nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	lostChs, err := b.hold(s, resources)
+	return lostChs, "", err
+}
+
+// contended returns a key of resources held by someone else, or the first
+// key when they have all been released since the collision.
+func (b *storeBackend) contended(resources []Resource) string {
+	for _, resource := range resources {
+		existing, err := b.store.Fetch(resource.Key)
+		if err == nil && existing.Owner != resource.Owner {
+			return resource.Key
+		}
+	}
+
+	return resources[0].Key
+}
+
+// hold records resources locked for the session, and releases them again if
+// the session was destroyed while they were being locked.
+func (b *storeBackend) hold(s *storeSession, resources []Resource) ([]<-chan struct{}, error) {
+	b.lock.Lock()
+	if _, ok := b.sessions[resources[0].Owner]; !ok {
+		b.lock.Unlock()
+		b.release(resources)
+		return nil, ErrInvalidSession
+	}
+	defer b.lock.Unlock()
+
+	lostChs := make([]<-chan struct{}, 0, len(resources))
+	for _, resource := range resources {
+		k, ok := s.keys[resource.Key]
+		if !ok {
+			k = &storeKey{lostCh: make(chan struct{})}
+			s.keys[resource.Key] = k
+		}
+		k.resource = resource
+		lostChs = append(lostChs, k.lostCh)
+	}
+
+	return lostChs, nil
+}
+
+func (b *storeBackend) ReleaseKey(sessionID, key string) error {
+	b.lock.Lock()
+	s, ok := b.sessions[sessionID]
+	if !ok {
+		b.lock.Unlock()
+		return ErrInvalidSession
+	}
+
+	k, ok := s.keys[key]
+	if ok {
+		delete(s.keys, key)
+		close(k.lostCh)
+	}
+	b.lock.Unlock()
+
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	return b.release([]Resource{k.resource})
+}
+
+func (b *storeBackend) UpdateKey(sessionID, key string, value []byte) error {
+	b.lock.Lock()
+	s, ok := b.sessions[sessionID]
+	var k *storeKey
+	if ok {
+		k, ok = s.keys[key]
+	}
+	b.lock.Unlock()
+	if !ok {
+		return ErrInvalidSession
+	}
+
+	// locking a resource the session already owns refreshes it
+	resource := k.resource
+	resource.Value = value
+	lostCh, _, err := b.tryAcquire(s, resource)
+	if err != nil {
+		return err
+	}
+	if lostCh == nil {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+func (b *storeBackend) ListPrefix(prefix string, waitIndex uint64, waitTime time.Duration) ([]KeyValue, uint64, error) {
+	var kvs []KeyValue
+
+	index, err := b.poll(waitIndex, waitTime, func() (uint64, error) {
+		resources, err := b.store.FetchAll("")
+		if err != nil {
+			return 0, err
+		}
+
+		kvs = listResources(prefix, resources)
+		return fingerprint(kvs), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kvs, index, nil
+}
+
+func (b *storeBackend) WatchKey(key string, waitIndex uint64, waitTime time.Duration) (*KeyValue, uint64, error) {
+	var kv *KeyValue
+
+	index, err := b.poll(waitIndex, waitTime, func() (uint64, error) {
+		resource, err := b.store.Fetch(key)
+		if err == ErrResourceNotFound {
+			kv = nil
+			return fingerprint(nil), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		kv = resourceKeyValue(*resource)
+		return fingerprint([]KeyValue{*kv}), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kv, index, nil
+}
+
+// poll fetches until the fingerprint returned by fetch differs from
+// waitIndex or waitTime elapses.
+func (b *storeBackend) poll(waitIndex uint64, waitTime time.Duration, fetch func() (uint64, error)) (uint64, error) {
+	var deadline <-chan time.Time
+	if waitTime > 0 {
+		timer := b.clock.NewTimer(waitTime)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	for {
+		index, err := fetch()
+		if err != nil {
+			return 0, err
+		}
+
+		if waitIndex == 0 || index != waitIndex || deadline == nil {
+			return index, nil
+		}
+
+		timer := b.clock.NewTimer(b.pollInterval)
+		select {
+		case <-timer.C():
+		case <-deadline:
+			timer.Stop()
+			return index, nil
+		}
+	}
+}
+
+var errUnownedKeys = errors.New("lock store backend cannot write unowned keys")
+
+// PutKey is not supported: every record in a LockStore has an owner. This
+// also rules out ForceRelease, which records its revocation with PutKey.
+func (b *storeBackend) PutKey(key string, value []byte) error {
+	return errUnownedKeys
+}
+
+func (b *storeBackend) DeleteKey(key string) error {
+	return errUnownedKeys
+}
+
+func (b *storeBackend) GetKey(key string) (*KeyValue, error) {
+	kv, _, err := b.WatchKey(key, 0, 0)
+	return kv, err
+}
+
+func (b *storeBackend) renew(id string, s *storeSession) error {
+	b.lock.Lock()
+	resources := make([]Resource, 0, len(s.keys))
+	for _, k := range s.keys {
+		resources = append(resources, k.resource)
+	}
+	b.lock.Unlock()
+
+	for _, resource := range resources {
+		_, err := b.store.Lock(resource, s.ttl)
+		if err == ErrLockCollision {
+			b.lose(s, resource.Key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *storeBackend) lose(s *storeSession, key string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if k, ok := s.keys[key]; ok {
+		delete(s.keys, key)
+		close(k.lostCh)
+	}
+}
+
+// Lock must be held
+func (b *storeBackend) invalidate(id string) []Resource {
+	s, ok := b.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	delete(b.sessions, id)
+	close(s.doneCh)
+
+	resources := make([]Resource, 0, len(s.keys))
+	for _, k := range s.keys {
+		resources = append(resources, k.resource)
+		close(k.lostCh)
+	}
+
+	return resources
+}
+
+func (b *storeBackend) release(resources []Resource) error {
+	var firstErr error
+	for _, resource := range resources {
+		err := b.store.Release(resource)
+		if err != nil && err != ErrResourceNotFound && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func resourceKeyValue(r Resource) *KeyValue {
+	return &KeyValue{Key: r.Key, Value: r.Value, Session: r.Owner, Type: r.Type}
+}
+
+func listResources(prefix string, resources []Resource) []KeyValue {
+	var kvs []KeyValue
+	for _, r := range resources {
+		if strings.HasPrefix(r.Key, prefix) {
+			kvs = append(kvs, *resourceKeyValue(r))
+		}
+	}
+
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+// fingerprint stands in for a modify index, which a LockStore does not
+// expose. It is never 0.
+func fingerprint(kvs []KeyValue) uint64 {
+	h := fnv.New64a()
+	for _, kv := range kvs {
+		h.Write([]byte(kv.Key))
+		h.Write([]byte{0})
+		h.Write([]byte(kv.Session))
+		h.Write([]byte{0})
+		h.Write(kv.Value)
+		h.Write([]byte{0})
+	}
+
+	index := h.Sum64()
+	if index == 0 {
+		index = 1
+	}
+
+	return index
+}
@@ -0,0 +1,180 @@
+package locket_test
+
+import (
+	"database/sql"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/locket"
+	"code.cloudfoundry.org/locket/db"
+	"code.cloudfoundry.org/locket/fakes"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("LockStoreBackend", func() {
+	const (
+		ttl          = 10 * time.Second
+		pollInterval = 100 * time.Millisecond
+	)
+
+	var (
+		sqlConn *sql.DB
+		sqlDB   *db.SQLDB
+		clock   *fakeclock.FakeClock
+		backend locket.Backend
+		logger  *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		var err error
+		sqlConn, err = sql.Open(db.SQLite, ":memory:")
+		Expect(err).NotTo(HaveOccurred())
+		sqlConn.SetMaxOpenConns(1)
+
+		sqlDB = db.NewSQLDB(sqlConn, db.SQLite)
+		Expect(sqlDB.CreateLockTable()).To(Succeed())
+
+		clock = fakeclock.NewFakeClock(time.Now())
+		backend = locket.NewLockStoreBackend(sqlDB, clock, pollInterval)
+		logger = lagertest.NewTestLogger("locket")
+	})
+
+	AfterEach(func() {
+		sqlConn.Close()
+	})
+
+	It("keeps a presence in the store while it is running", func() {
+		presence := locket.NewPresenceWithBackend(logger, backend, "v1/locks/cell/some-cell", []byte("cell"), clock, time.Second, ttl)
+		process := ifrit.Background(presence)
+		Eventually(process.Ready()).Should(BeClosed())
+
+		resources, err := sqlDB.FetchAll(locket.PresenceType)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].Value).To(Equal([]byte("cell")))
+
+		ginkgomon.Interrupt(process)
+		Eventually(func() ([]locket.Resource, error) {
+			return sqlDB.FetchAll("")
+		}).Should(BeEmpty())
+	})
+
+	It("hands a lock over once the holder releases it", func() {
+		key := locket.LockSchemaPath("some-key")
+
+		holder := ifrit.Background(locket.NewLockWithBackend(logger, backend, key, []byte("a"), clock, time.Second, ttl))
+		Eventually(holder.Ready()).Should(BeClosed())
+
+		waiter := ifrit.Background(locket.NewLockWithBackend(logger, backend, key, []byte("b"), clock, time.Second, ttl))
+		defer ginkgomon.Kill(waiter)
+		Consistently(waiter.Ready()).ShouldNot(BeClosed())
+
+		ginkgomon.Interrupt(holder)
+		Eventually(func() <-chan struct{} {
+			clock.Increment(pollInterval)
+			return waiter.Ready()
+		}).Should(BeClosed())
+
+		resource, err := sqlDB.Fetch(key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resource.Value).To(Equal([]byte("b")))
+	})
+
+	It("does not support ForceRelease, and leaves the lock with its holder", func() {
+		key := locket.LockSchemaPath("some-key")
+
+		holder := ifrit.Background(locket.NewLockWithBackend(logger, backend, key, []byte("a"), clock, time.Second, ttl))
+		defer ginkgomon.Kill(holder)
+		Eventually(holder.Ready()).Should(BeClosed())
+
+		_, err := locket.ForceReleaseWithBackend(backend, clock, locket.ForceReleaseRequest{
+			Key:      key,
+			Operator: "some-operator",
+			Reason:   "wedged",
+		})
+		Expect(err).To(Equal(locket.ErrForceReleaseUnsupported))
+
+		Consistently(holder.Wait()).ShouldNot(Receive())
+		resource, err := sqlDB.Fetch(key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resource.Value).To(Equal([]byte("a")))
+	})
+
+	It("writes none of a MultiLock's keys while one of them is taken", func() {
+		keyA := locket.LockSchemaPath("a")
+		keyB := locket.LockSchemaPath("b")
+
+		holder := ifrit.Background(locket.NewLockWithBackend(logger, backend, keyB, []byte("single"), clock, time.Second, ttl))
+		Eventually(holder.Ready()).Should(BeClosed())
+
+		multi := ifrit.Background(locket.NewMultiLockWithBackend(logger, backend, []string{keyA, keyB}, []byte("multi"), clock, time.Second, ttl))
+		defer ginkgomon.Kill(multi)
+		Eventually(logger).Should(Say("waiting-for-contended-lock"))
+
+		_, err := sqlDB.Fetch(keyA)
+		Expect(err).To(Equal(locket.ErrResourceNotFound))
+
+		ginkgomon.Interrupt(holder)
+		Eventually(func() <-chan struct{} {
+			clock.Increment(pollInterval)
+			return multi.Ready()
+		}).Should(BeClosed())
+
+		resources, err := sqlDB.FetchAll(locket.LockType)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources).To(HaveLen(2))
+	})
+
+	It("loses a presence whose record is expired", func() {
+		presence := locket.NewPresenceWithBackend(logger, backend, "some-presence", []byte("value"), clock, time.Second, ttl)
+		process := ifrit.Background(presence)
+		defer ginkgomon.Kill(process)
+		Eventually(process.Ready()).Should(BeClosed())
+
+		resource, err := sqlDB.Fetch("some-presence")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sqlDB.Release(*resource)).To(Succeed())
+		_, err = sqlDB.Lock(locket.Resource{Key: "some-presence", Owner: "someone-else"}, ttl)
+		Expect(err).NotTo(HaveOccurred())
+
+		clock.WaitForWatcherAndIncrement(ttl / 2)
+		Eventually(logger).Should(Say("presence-lost"))
+	})
+
+	It("does not hold up other sessions while it releases a lock taken by a destroyed session", func() {
+		store := &fakes.FakeLockStore{}
+		backend = locket.NewLockStoreBackend(store, clock, pollInterval)
+
+		sessionID, err := backend.CreateSession("some-session", ttl, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		store.LockStub = func(locket.Resource, time.Duration) (int64, error) {
+			backend.DestroySession(sessionID)
+			return 1, nil
+		}
+		releasing := make(chan struct{})
+		defer close(releasing)
+		store.ReleaseStub = func(locket.Resource) error {
+			<-releasing
+			return nil
+		}
+
+		go backend.TryAcquireKey(sessionID, "some-key", []byte("value"), locket.LockType)
+		Eventually(store.ReleaseCallCount).Should(Equal(1))
+
+		created := make(chan error, 1)
+		go func() {
+			_, err := backend.CreateSession("other-session", ttl, true)
+			created <- err
+		}()
+		Eventually(created).Should(Receive(BeNil()))
+	})
+})